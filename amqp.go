@@ -0,0 +1,44 @@
+package glob
+
+import (
+	"strings"
+)
+
+const (
+	amqpOne  = "*"
+	amqpMany = "#"
+
+	amqpMaxLength = 255
+)
+
+// compileAMQP compiles the binding key of a topic exchange:
+//
+//   - words are separated by a dot and compared literally
+//   - * matches exactly one word
+//   - # matches zero or more words and can appear anywhere in the key
+//
+// Like brokers do, * and # are only wildcards when they form a whole word.
+func compileAMQP(pattern string, _ *config) (Matcher, error) {
+	if len(pattern) > amqpMaxLength {
+		return nil, patternError(pattern, amqpMaxLength, "amqp: binding key too long (%d bytes)", len(pattern))
+	}
+	var (
+		words = strings.Split(pattern, ".")
+		ms    = make([]Matcher, 0, len(words))
+	)
+	for _, w := range words {
+		switch w {
+		case amqpOne:
+			ms = append(ms, &simple{pattern: string(star)})
+		case amqpMany:
+			// consecutive # are the same as one #
+			if n := len(ms); n > 0 && isGlobstar(ms[n-1]) {
+				continue
+			}
+			ms = append(ms, &simple{pattern: "**"})
+		default:
+			ms = append(ms, &simple{pattern: quote(w)})
+		}
+	}
+	return linkMatchers(ms), nil
+}
@@ -0,0 +1,408 @@
+package glob
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// errComplex is returned when the analysis of matchers would need too many
+// states or when they contain matchers defined outside of this package.
+var errComplex = errors.New("matchers can not be analyzed")
+
+const maxRegions = 1024
+
+// Subsumes reports whether every path matched by b is also matched by a. It
+// reports false when the matchers can not be analyzed. The separators are
+// chosen as for Witness.
+func Subsumes(a, b Matcher, opts ...Option) bool {
+	_, found, err := findPath(And(b, Not(a)), configureFor(opts, a, b))
+	return err == nil && !found
+}
+
+// Overlaps reports whether a path is matched by both a and b. It reports
+// true when the matchers can not be analyzed. The separators are chosen as for
+// Witness.
+func Overlaps(a, b Matcher, opts ...Option) bool {
+	_, found, err := findPath(And(a, b), configureFor(opts, a, b))
+	return err != nil || found
+}
+
+// Witness gives one of the shortest paths matched by both a and b. The
+// segments of the path are joined by the separator of the dialect given in
+// opts or, without options, of the first Pattern among a and b: a slash, a
+// dot for AMQP and Hostname and a backslash for Windows.
+func Witness(a, b Matcher, opts ...Option) (string, bool) {
+	str, found, err := findPath(And(a, b), configureFor(opts, a, b))
+	return str, err == nil && found
+}
+
+// IsEmpty reports whether m can never match a path, which is the case of a
+// nil Matcher. It reports false when m can not be analyzed. The separators are
+// chosen as for Witness.
+func IsEmpty(m Matcher, opts ...Option) bool {
+	_, found, err := findPath(m, configureFor(opts, m))
+	return err == nil && !found
+}
+
+// analysis explores the paths matched by a boolean combination of programs.
+// The segments are handled by partitioning the strings in regions of strings
+// matched by the same segment nodes, the regions being expressions of a
+// builder shared by all the programs.
+type analysis struct {
+	builder *builder
+	progs   []*program
+	starts  []int
+	closure [][][]int32
+	// exprs gives the expression of the segment nodes of each program
+	exprs [][]*expr
+	// segment is the expression of the strings without separator
+	segment *expr
+}
+
+// pathState holds the active nodes of each program after a path.
+type pathState struct {
+	sets   [][]int32
+	parent int
+	seg    string
+}
+
+// findPath looks for one of the shortest paths matched by m with a breadth
+// first search, its segments being split by the separators of cfg.
+func findPath(m Matcher, cfg *config) (string, bool, error) {
+	a := analysis{
+		builder: newBuilder(),
+	}
+	accept, err := a.term(m)
+	if err != nil {
+		return "", false, err
+	}
+	b := a.builder
+	var seps []runeRange
+	for _, k := range cfg.separators() {
+		seps = append(seps, runeRange{lo: k, hi: k})
+	}
+	sep := b.set(normalizeRanges(seps))
+	a.segment = b.not(b.cat(b.all, b.cat(sep, b.all)))
+
+	join := cfg.separators()[:1]
+
+	start := pathState{parent: -1}
+	for i := range a.progs {
+		start.sets = append(start.sets, a.closure[i][a.starts[i]])
+	}
+	var (
+		queue = []pathState{start}
+		seen  = map[string]bool{stateKey(start.sets): true}
+		// fallback is the first path found with an empty segment at its
+		// borders: such a segment is lost by the dialects trimming the
+		// separators of the paths
+		fallback []string
+	)
+	for i := 0; i < len(queue); i++ {
+		if len(queue) > maxStates {
+			return "", false, errComplex
+		}
+		next, err := a.transitions(queue[i], i)
+		if err != nil {
+			return "", false, err
+		}
+		for _, s := range next {
+			if accept(s.sets) {
+				parts := append(pathOf(queue, i), s.seg)
+				if n := len(parts); n == 1 || (parts[0] != "" && parts[n-1] != "") {
+					return strings.Join(parts, join), true, nil
+				}
+				if fallback == nil {
+					fallback = parts
+				}
+			}
+			k := stateKey(s.sets)
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			queue = append(queue, s)
+		}
+	}
+	if fallback != nil {
+		return strings.Join(fallback, join), true, nil
+	}
+	return "", false, nil
+}
+
+// term adds the programs of m and returns the function evaluating whether a
+// state is accepted by m.
+func (a *analysis) term(m Matcher) (func([][]int32) bool, error) {
+	if isNil(m) {
+		// no segment can be matched by an empty program
+		return func([][]int32) bool { return false }, nil
+	}
+	switch m := m.(type) {
+	case *Pattern:
+		return a.term(m.Matcher)
+	case *complement:
+		f, err := a.term(m.inner)
+		if err != nil {
+			return nil, err
+		}
+		return func(sets [][]int32) bool {
+			return !f(sets)
+		}, nil
+	case *intersect:
+		var fs []func([][]int32) bool
+		for _, m := range m.ms {
+			f, err := a.term(m)
+			if err != nil {
+				return nil, err
+			}
+			fs = append(fs, f)
+		}
+		return func(sets [][]int32) bool {
+			for _, f := range fs {
+				if !f(sets) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	}
+	var p program
+	p.nodes = append(p.nodes, node{kind: nodeFinal})
+	start, ok := p.chain(automate(m), 0)
+	if !ok {
+		return nil, errComplex
+	}
+
+	var (
+		i       = len(a.progs)
+		closure = make([][]int32, len(p.nodes))
+		exprs   = make([]*expr, len(p.nodes))
+	)
+	for j, n := range p.nodes {
+		closure[j] = p.closeAll(j)
+		sort.Slice(closure[j], func(x, y int) bool {
+			return closure[j][x] < closure[j][y]
+		})
+		if n.kind == nodeSegment {
+			if exprs[j], ok = a.builder.fromMatcher(n.m); !ok {
+				return nil, errComplex
+			}
+		}
+	}
+	a.progs = append(a.progs, &p)
+	a.starts = append(a.starts, start)
+	a.closure = append(a.closure, closure)
+	a.exprs = append(a.exprs, exprs)
+	return func(sets [][]int32) bool {
+		return hasNode(sets[i], 0)
+	}, nil
+}
+
+// transitions gives the states following s, the one at index parent, for
+// each region of segments.
+func (a *analysis) transitions(s pathState, parent int) ([]pathState, error) {
+	var (
+		b     = a.builder
+		exprs []*expr
+		index = make(map[*expr]int)
+	)
+	for i, set := range s.sets {
+		for _, j := range set {
+			x := a.exprs[i][j]
+			if x == nil {
+				continue
+			}
+			if _, ok := index[x]; !ok {
+				index[x] = len(exprs)
+				exprs = append(exprs, x)
+			}
+		}
+	}
+	type region struct {
+		expr *expr
+		in   []bool
+	}
+	// the empty segment is kept apart to give paths without empty segments
+	// when possible
+	regions := []region{
+		{expr: b.and(a.segment, b.not(b.eps))},
+		{expr: b.eps},
+	}
+	for _, x := range exprs {
+		var list []region
+		for _, r := range regions {
+			for _, in := range []bool{true, false} {
+				y := x
+				if !in {
+					y = b.not(x)
+				}
+				z := b.and(r.expr, y)
+				_, ok, err := b.shortest(z)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					continue
+				}
+				xs := append(make([]bool, 0, len(exprs)), r.in...)
+				list = append(list, region{expr: z, in: append(xs, in)})
+			}
+		}
+		if len(list) > maxRegions {
+			return nil, errComplex
+		}
+		regions = list
+	}
+	var next []pathState
+	for _, r := range regions {
+		seg, _, err := b.shortest(r.expr)
+		if err != nil {
+			return nil, err
+		}
+		x := pathState{
+			parent: parent,
+			seg:    seg,
+		}
+		for i, set := range s.sets {
+			var list []int32
+			for _, j := range set {
+				switch n := a.progs[i].nodes[j]; n.kind {
+				case nodeGlobstar:
+					list = append(list, a.closure[i][j]...)
+				case nodeSegment:
+					if r.in[index[a.exprs[i][j]]] {
+						list = append(list, a.closure[i][n.next]...)
+					}
+				}
+			}
+			x.sets = append(x.sets, uniqueNodes(list))
+		}
+		next = append(next, x)
+	}
+	return next, nil
+}
+
+// shortest gives one of the shortest strings matched by x. It reports false
+// when x matches nothing.
+func (b *builder) shortest(x *expr) (string, bool, error) {
+	type item struct {
+		expr   *expr
+		parent int
+		char   rune
+	}
+	var (
+		queue = []item{{expr: x, parent: -1}}
+		seen  = map[*expr]bool{x: true}
+	)
+	for i := 0; i < len(queue); i++ {
+		if len(queue) > maxStates {
+			return "", false, errComplex
+		}
+		it := queue[i]
+		if it.expr.nullable {
+			var rs []rune
+			for j := i; queue[j].parent >= 0; j = queue[j].parent {
+				rs = append(rs, queue[j].char)
+			}
+			for l, r := 0, len(rs)-1; l < r; l, r = l+1, r-1 {
+				rs[l], rs[r] = rs[r], rs[l]
+			}
+			return string(rs), true, nil
+		}
+		bounds := b.classes(it.expr)
+		for _, j := range letterFirst(bounds) {
+			lo, hi := bounds[j], rune(utf8.MaxRune)
+			if j+1 < len(bounds) {
+				hi = bounds[j+1] - 1
+			}
+			k := printable(lo, hi)
+			d := b.derive(it.expr, k)
+			if d == b.none || seen[d] {
+				continue
+			}
+			seen[d] = true
+			queue = append(queue, item{expr: d, parent: i, char: k})
+		}
+	}
+	return "", false, nil
+}
+
+// letterFirst gives the indices of bounds, the one of the class containing
+// the letter a first.
+func letterFirst(bounds []rune) []int {
+	list := make([]int, 0, len(bounds))
+	for j := range bounds {
+		if bounds[j] <= 'a' && (j+1 == len(bounds) || 'a' < bounds[j+1]) {
+			list = append([]int{j}, list...)
+			continue
+		}
+		list = append(list, j)
+	}
+	return list
+}
+
+// printable picks a character between lo and hi, a letter or a printable
+// ASCII character when possible.
+func printable(lo, hi rune) rune {
+	switch {
+	case lo <= 'a' && 'a' <= hi:
+		return 'a'
+	case lo <= '~' && '!' <= hi:
+		return max(lo, '!')
+	default:
+		for k := lo; k <= hi && k < lo+256; k++ {
+			if utf8.ValidRune(k) {
+				return k
+			}
+		}
+		return lo
+	}
+}
+
+// pathOf gives the segments of the path leading to the state at index i.
+func pathOf(queue []pathState, i int) []string {
+	var parts []string
+	for ; queue[i].parent >= 0; i = queue[i].parent {
+		parts = append(parts, queue[i].seg)
+	}
+	for l, r := 0, len(parts)-1; l < r; l, r = l+1, r-1 {
+		parts[l], parts[r] = parts[r], parts[l]
+	}
+	return parts
+}
+
+func stateKey(sets [][]int32) string {
+	var buf strings.Builder
+	for _, set := range sets {
+		for _, i := range set {
+			buf.WriteString(strconv.Itoa(int(i)))
+			buf.WriteByte(',')
+		}
+		buf.WriteByte(';')
+	}
+	return buf.String()
+}
+
+func uniqueNodes(list []int32) []int32 {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i] < list[j]
+	})
+	var j int
+	for i := range list {
+		if i == 0 || list[i] != list[j-1] {
+			list[j] = list[i]
+			j++
+		}
+	}
+	return list[:j]
+}
+
+func hasNode(set []int32, i int32) bool {
+	j := sort.Search(len(set), func(j int) bool {
+		return set[j] >= i
+	})
+	return j < len(set) && set[j] == i
+}
@@ -0,0 +1,168 @@
+package glob
+
+import (
+	"testing"
+)
+
+func TestSubsumes(t *testing.T) {
+	data := []struct {
+		A, B string
+		Want bool
+	}{
+		{A: "src/**", B: "src/*.go", Want: true},
+		{A: "src/*.go", B: "src/**", Want: false},
+		{A: "**", B: "a/b/c", Want: true},
+		{A: "**/*.go", B: "src/**/*_test.go", Want: true},
+		{A: "src/**/*_test.go", B: "**/*.go", Want: false},
+		{A: "*.!(txt)", B: "*.go", Want: true},
+		{A: "*.!(go)", B: "*.go", Want: false},
+		{A: "!(*.go)", B: "*.txt", Want: true},
+		{A: "*", B: "?(a|b)", Want: true},
+		{A: "@(a|b)", B: "?(a|b)", Want: false},
+		{A: "a/**/b", B: "a/b", Want: true},
+		{A: "a/**/b", B: "a/*/b", Want: true},
+		{A: "a/*/b", B: "a/**/b", Want: false},
+		{A: "*", B: ".git", Want: true},
+		{A: "@(a/b|c)/**", B: "a/b/c", Want: true},
+	}
+	for _, d := range data {
+		a, err := Compile(d.A)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", d.A, err)
+		}
+		b, err := Compile(d.B)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", d.B, err)
+		}
+		if got := Subsumes(a, b); got != d.Want {
+			t.Errorf("%q subsumes %q: want %t, got %t", d.A, d.B, d.Want, got)
+		}
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	data := []struct {
+		A, B    string
+		Witness string
+		Want    bool
+	}{
+		{A: "src/**", B: "**/*.go", Witness: "src/.go", Want: true},
+		{A: "*.go", B: "*.txt", Want: false},
+		{A: "a*", B: "*b", Witness: "ab", Want: true},
+		{A: "**/testdata/**", B: "src/*/*.txt", Witness: "src/testdata/.txt", Want: true},
+		{A: "!(*.go)", B: "*.go", Want: false},
+		{A: "*.!(go)", B: "main.*", Witness: "main.", Want: true},
+		{A: "a/**", B: "b/**", Want: false},
+		{A: "**/b", B: "a/**", Witness: "a/b", Want: true},
+		{A: "a/*", B: "a/*", Witness: "a/a", Want: true},
+		{A: "a/b/*", B: "a/b/*", Witness: "a/b/a", Want: true},
+		{A: "*/x", B: "*/x", Witness: "a/x", Want: true},
+		{A: "*", B: "*", Witness: "a", Want: true},
+	}
+	for _, d := range data {
+		a, err := Compile(d.A)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", d.A, err)
+		}
+		b, err := Compile(d.B)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", d.B, err)
+		}
+		if got := Overlaps(a, b); got != d.Want {
+			t.Errorf("%q overlaps %q: want %t, got %t", d.A, d.B, d.Want, got)
+		}
+		str, ok := Witness(a, b)
+		if ok != d.Want || str != d.Witness {
+			t.Errorf("%q, %q: want witness %q, got %q", d.A, d.B, d.Witness, str)
+		}
+		if ok && (MatchWith(str, a) != nil || MatchWith(str, b) != nil) {
+			t.Errorf("%q, %q: witness %q not matched", d.A, d.B, str)
+		}
+	}
+}
+
+func TestWitnessDialect(t *testing.T) {
+	data := []struct {
+		A, B    string
+		Witness string
+		Opts    []Option
+	}{
+		{A: "stock.*.nyse", B: "#.nyse", Witness: "stock.nyse.nyse", Opts: []Option{WithDialect(AMQP)}},
+		{A: "stock.#", B: "*.usd", Witness: "stock.usd", Opts: []Option{WithDialect(AMQP)}},
+		{A: `src\*.go`, B: `*\main.*`, Witness: `src\main.go`, Opts: []Option{WithDialect(Windows)}},
+		{A: `src\*`, B: `*\[/.]`, Witness: `src\.`, Opts: []Option{WithDialect(Windows)}},
+	}
+	for _, d := range data {
+		a, err := CompilePattern(d.A, d.Opts...)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", d.A, err)
+		}
+		b, err := CompilePattern(d.B, d.Opts...)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", d.B, err)
+		}
+		str, ok := Witness(a, b)
+		if !ok || str != d.Witness {
+			t.Errorf("%q, %q: want witness %q, got %q", d.A, d.B, d.Witness, str)
+			continue
+		}
+		if !a.MatchString(str) || !b.MatchString(str) {
+			t.Errorf("%q, %q: witness %q not matched", d.A, d.B, str)
+		}
+	}
+}
+
+func TestAnalysisCustom(t *testing.T) {
+	m, _ := Compile("src/**")
+	if Subsumes(m, Seq(m, version{})) {
+		t.Errorf("custom matchers should not be analyzed")
+	}
+	if !Overlaps(m, Seq(m, version{})) {
+		t.Errorf("custom matchers should be reported to overlap")
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	data := []struct {
+		Pattern string
+		Dialect Dialect
+		Empty   bool
+	}{
+		{Pattern: "*.go!(*)", Empty: true},
+		{Pattern: "@(a)!(a)", Empty: false},
+		{Pattern: "!(*)", Empty: true},
+		{Pattern: "src/!(*)/**", Empty: true},
+		{Pattern: "a+(!(*))", Empty: true},
+		{Pattern: "*(!(*))", Empty: false},
+		{Pattern: "[!\x00-\U0010FFFF]", Empty: true},
+		{Pattern: "!(*a)b!(b*)", Empty: false},
+		{Pattern: "a/b", Empty: false},
+		{Pattern: "**", Empty: false},
+		{Pattern: "sport/#", Dialect: MQTT, Empty: false},
+	}
+	for _, d := range data {
+		m, err := Compile(d.Pattern, WithDialect(d.Dialect))
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", d.Pattern, err)
+			continue
+		}
+		if got := IsEmpty(m); got != d.Empty {
+			t.Errorf("%q: empty should be %t, got %t", d.Pattern, d.Empty, got)
+		}
+		_, err = Compile(d.Pattern, WithDialect(d.Dialect), WithStrict())
+		if d.Empty && err == nil {
+			t.Errorf("%q: strict mode should reject the pattern", d.Pattern)
+		}
+		if !d.Empty && err != nil {
+			t.Errorf("%q: unexpected error in strict mode: %s", d.Pattern, err)
+		}
+	}
+	if !IsEmpty(nil) || !IsEmpty((*element)(nil)) {
+		t.Errorf("nil matcher should be empty")
+	}
+	for _, p := range []string{"//", "/"} {
+		if _, err := Compile(p, WithStrict()); err == nil {
+			t.Errorf("%q: strict mode should reject the pattern", p)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Package ast declares the types used to represent the syntax tree of glob
+// patterns.
+//
+// Every node records its position in the parsed pattern as byte offsets: Pos
+// gives the offset of its first character and End the offset of the character
+// immediately following it.
+package ast
+
+// Node is implemented by all the nodes of the tree. String gives the node in
+// the syntax it has been parsed from, without the text having no effect like
+// redundant stars and escape characters.
+type Node interface {
+	Pos() int
+	End() int
+	String() string
+}
+
+// Pattern is a sequence of segments. It is the root of the tree returned by
+// Parse and the content of each alternative of a group or an extglob.
+type Pattern struct {
+	From, To int
+	Segments []*Segment
+}
+
+// Segment holds the nodes found between two separators. A Segment of a
+// Pattern can be empty when the pattern is an empty alternative.
+type Segment struct {
+	From, To int
+	// Negate is set by zsh ^pattern
+	Negate bool
+	Nodes  []Node
+}
+
+// Literal is text matched as is. Value is the text once escape characters
+// have been removed.
+type Literal struct {
+	From, To int
+	Value    string
+}
+
+// WildcardKind identifies the kind of a Wildcard.
+type WildcardKind int
+
+const (
+	Star     WildcardKind = iota // *
+	Question                     // ?
+	Globstar                     // ** as a whole segment
+)
+
+func (k WildcardKind) String() string {
+	switch k {
+	case Star:
+		return "*"
+	case Question:
+		return "?"
+	case Globstar:
+		return "**"
+	default:
+		return "unknown"
+	}
+}
+
+// Wildcard is one of *, ? or **.
+type Wildcard struct {
+	From int
+	Kind WildcardKind
+}
+
+// Range is a range of characters of a bracket expression. Lo and Hi are
+// equal for a single character.
+type Range struct {
+	Lo, Hi rune
+}
+
+// Class is a bracket expression.
+type Class struct {
+	From, To int
+	Negate   bool
+	Ranges   []Range
+}
+
+// ExtGlob is one of the extended patterns of bash: @(...), !(...), *(...),
+// +(...) and ?(...). Kind is the character preceding the parenthesis.
+type ExtGlob struct {
+	From, To int
+	Kind     rune
+	Alts     []*Pattern
+}
+
+// Group is a list of alternatives written {a,b} or, in zsh, (a|b). Open is
+// the character starting the group.
+type Group struct {
+	From, To int
+	Open     rune
+	Alts     []*Pattern
+}
+
+// Repeat is the zsh x# (Min is 0) and x## (Min is 1) operator.
+type Repeat struct {
+	From, To int
+	Min      int
+	Node     Node
+}
+
+func (p *Pattern) Pos() int  { return p.From }
+func (p *Pattern) End() int  { return p.To }
+func (s *Segment) Pos() int  { return s.From }
+func (s *Segment) End() int  { return s.To }
+func (i *Literal) Pos() int  { return i.From }
+func (i *Literal) End() int  { return i.To }
+func (w *Wildcard) Pos() int { return w.From }
+func (c *Class) Pos() int    { return c.From }
+func (c *Class) End() int    { return c.To }
+func (e *ExtGlob) Pos() int  { return e.From }
+func (e *ExtGlob) End() int  { return e.To }
+func (g *Group) Pos() int    { return g.From }
+func (g *Group) End() int    { return g.To }
+func (r *Repeat) Pos() int   { return r.From }
+func (r *Repeat) End() int   { return r.To }
+
+func (w *Wildcard) End() int {
+	if w.Kind == Globstar {
+		return w.From + 2
+	}
+	return w.From + 1
+}
+
+// Visitor's Visit method is called by Walk for each node. If the returned
+// visitor w is not nil, Walk visits each of the children of node with w,
+// followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses the tree rooted at node in depth-first order.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	switch n := node.(type) {
+	case *Pattern:
+		for _, s := range n.Segments {
+			Walk(v, s)
+		}
+	case *Segment:
+		for _, x := range n.Nodes {
+			Walk(v, x)
+		}
+	case *ExtGlob:
+		for _, a := range n.Alts {
+			Walk(v, a)
+		}
+	case *Group:
+		for _, a := range n.Alts {
+			Walk(v, a)
+		}
+	case *Repeat:
+		Walk(v, n.Node)
+	}
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses the tree rooted at node in depth-first order. It calls
+// f(node) for each node and then, if f returns true, visits its children
+// followed by a call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
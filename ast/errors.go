@@ -0,0 +1,57 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// PatternError reports a malformed pattern. Offset is the position in bytes in
+// Pattern of the offending character.
+type PatternError struct {
+	Pattern string
+	Offset  int
+	Msg     string
+}
+
+func (e *PatternError) Error() string {
+	return fmt.Sprintf("%s (offset %d in %q)", e.Msg, e.Offset, e.Pattern)
+}
+
+// Caret returns the line of the pattern holding the offending character with,
+// on the following line, a caret under it.
+func (e *PatternError) Caret() string {
+	var (
+		offset = e.Offset
+		line   = e.Pattern
+	)
+	if offset > len(line) {
+		offset = len(line)
+	}
+	if i := strings.LastIndexByte(line[:offset], newline); i >= 0 {
+		line, offset = line[i+1:], offset-i-1
+	}
+	if i := strings.IndexByte(line, newline); i >= 0 {
+		line = line[:i]
+	}
+	var buf strings.Builder
+	buf.WriteString(line)
+	buf.WriteRune(newline)
+	for _, k := range line[:offset] {
+		if k == tab {
+			buf.WriteRune(tab)
+		} else if k != utf8.RuneError {
+			buf.WriteRune(space)
+		}
+	}
+	buf.WriteRune(caret)
+	return buf.String()
+}
+
+func patternError(pattern string, offset int, msg string, args ...interface{}) error {
+	return &PatternError{
+		Pattern: pattern,
+		Offset:  offset,
+		Msg:     fmt.Sprintf(msg, args...),
+	}
+}
@@ -0,0 +1,400 @@
+package ast
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	slash     = '/'
+	backslash = '\\'
+	dash      = '-'
+	star      = '*'
+	mark      = '?'
+	lsquare   = '['
+	rsquare   = ']'
+	bang      = '!'
+	caret     = '^'
+	lparen    = '('
+	rparen    = ')'
+	pipe      = '|'
+	arobase   = '@'
+	plus      = '+'
+	lbrace    = '{'
+	rbrace    = '}'
+	comma     = ','
+	hash      = '#'
+	newline   = '\n'
+	carriage  = '\r'
+	tab       = '\t'
+	space     = ' '
+)
+
+// Syntax describes the features recognized by the parser.
+type Syntax struct {
+	Escape     rune
+	Separators string
+	// characters negating a bracket expression
+	Negate string
+
+	ExtGlob  bool // @(...), !(...), *(...), +(...) and ?(...)
+	Globstar bool // ** as a whole segment
+	Braces   bool // {a,b}
+	Groups   bool // (a|b) without prefix
+	Zsh      bool // ^pattern, x# and x##
+	// ] is a literal when it is the first character of a bracket expression
+	Bracket bool
+	// escape followed by a newline continues the pattern on the next line
+	Continuation bool
+}
+
+// Extended is the syntax of the default dialect of the glob package.
+var Extended = Syntax{
+	Escape:       backslash,
+	Separators:   string(slash),
+	Negate:       string([]rune{bang, caret}),
+	ExtGlob:      true,
+	Globstar:     true,
+	Continuation: true,
+}
+
+// Parse parses pattern with the Extended syntax. Like Compile, it ignores the
+// spaces surrounding pattern. A malformed pattern is reported by a
+// *PatternError.
+func Parse(pattern string) (Node, error) {
+	from, to := TrimSpace(pattern, Extended.Escape)
+	return ParseRange(pattern, from, to, Extended)
+}
+
+// TrimSpace gives the bounds of pattern without its leading and trailing
+// spaces. A trailing space preceded by escape is kept.
+func TrimSpace(pattern string, escape rune) (int, int) {
+	var (
+		str  = strings.TrimSpace(pattern)
+		from = strings.Index(pattern, str)
+		to   = from + len(str)
+	)
+	if k, n := utf8.DecodeLastRuneInString(str); k == escape && to < len(pattern) && !escaped(str[:len(str)-n], escape) {
+		_, z := utf8.DecodeRuneInString(pattern[to:])
+		to += z
+	}
+	return from, to
+}
+
+// escaped reports whether the character following str is escaped, that is
+// whether str ends with an odd number of escape characters.
+func escaped(str string, escape rune) bool {
+	var odd bool
+	for strings.HasSuffix(str, string(escape)) {
+		odd = !odd
+		str = str[:len(str)-utf8.RuneLen(escape)]
+	}
+	return odd
+}
+
+// ParseRange parses the part of pattern between from and to with the given
+// syntax. The positions of the nodes and of the errors are offsets in pattern.
+func ParseRange(pattern string, from, to int, syn Syntax) (*Pattern, error) {
+	r := strings.NewReader(pattern[:to])
+	r.Seek(int64(from), io.SeekStart)
+
+	p := parser{
+		Reader: r,
+		syntax: syn,
+	}
+	n, err := p.parse("")
+	if e, ok := err.(*PatternError); ok {
+		e.Pattern = pattern
+	}
+	return n, err
+}
+
+type parser struct {
+	*strings.Reader
+	syntax Syntax
+}
+
+// parse parses segments until one of the characters of stop or the end of
+// the input.
+func (p *parser) parse(stop string) (*Pattern, error) {
+	var (
+		pat    = Pattern{From: p.offset()}
+		seg    = Segment{From: pat.From}
+		lit    *Literal
+		atom   int // offset of the last character added to lit
+		parens int
+		top    = stop == "" && (p.syntax.ExtGlob || p.syntax.Groups)
+	)
+	add := func(n Node) {
+		seg.Nodes = append(seg.Nodes, n)
+		lit = nil
+	}
+	write := func(pos int, str string) {
+		if lit == nil {
+			lit = &Literal{From: pos}
+			seg.Nodes = append(seg.Nodes, lit)
+		}
+		lit.Value += str
+		lit.To = p.offset()
+		atom = pos
+	}
+	segment := func(end int) {
+		seg.To = end
+		if seg.Negate && len(seg.Nodes) == 0 {
+			// a lone ^ is literal
+			seg.Negate = false
+			seg.Nodes = append(seg.Nodes, &Literal{From: seg.From, To: seg.To, Value: string(caret)})
+		}
+		if p.syntax.Globstar && isStar(seg.Nodes) {
+			seg.Nodes = []Node{&Wildcard{From: seg.Nodes[0].Pos(), Kind: Globstar}}
+		}
+		if len(seg.Nodes) > 0 {
+			s := seg
+			pat.Segments = append(pat.Segments, &s)
+		}
+		seg = Segment{From: p.offset()}
+		lit = nil
+	}
+
+	for {
+		pos := p.offset()
+		k, _, err := p.ReadRune()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		if parens == 0 && strings.ContainsRune(stop, k) {
+			p.UnreadRune()
+			break
+		}
+		switch {
+		case k == p.syntax.Escape:
+			if k, _, err = p.ReadRune(); err != nil {
+				return nil, patternError("", pos, "trailing escape character")
+			}
+			if k == carriage && p.syntax.Continuation {
+				if z, _, _ := p.ReadRune(); z == newline {
+					k = z
+				} else {
+					p.UnreadRune()
+				}
+			}
+			if k != newline || !p.syntax.Continuation {
+				write(pos, string(k))
+				continue
+			}
+			for {
+				k, _, _ = p.ReadRune()
+				if k != space && k != tab && k != newline && k != carriage {
+					break
+				}
+			}
+			p.UnreadRune()
+			continue
+		case strings.ContainsRune(p.syntax.Separators, k):
+			segment(pos)
+			continue
+		}
+		switch k {
+		case arobase, bang, plus, star, mark:
+			if !p.syntax.ExtGlob || !p.peek(lparen) {
+				switch k {
+				case star:
+					add(&Wildcard{From: pos, Kind: Star})
+				case mark:
+					add(&Wildcard{From: pos, Kind: Question})
+				default:
+					write(pos, string(k))
+				}
+				continue
+			}
+			p.ReadRune()
+			alts, err := p.parseAlts(pos, pipe, rparen)
+			if err != nil {
+				return nil, err
+			}
+			add(&ExtGlob{From: pos, To: p.offset(), Kind: k, Alts: alts})
+		case lsquare:
+			c, err := p.parseClass(pos)
+			if err != nil {
+				return nil, err
+			}
+			add(c)
+		case lbrace:
+			if !p.syntax.Braces {
+				write(pos, string(k))
+				continue
+			}
+			alts, err := p.parseAlts(pos, comma, rbrace)
+			if err != nil {
+				return nil, err
+			}
+			add(&Group{From: pos, To: p.offset(), Open: k, Alts: alts})
+		case lparen:
+			if !p.syntax.Groups {
+				parens++
+				write(pos, string(k))
+				continue
+			}
+			alts, err := p.parseAlts(pos, pipe, rparen)
+			if err != nil {
+				return nil, err
+			}
+			add(&Group{From: pos, To: p.offset(), Open: k, Alts: alts})
+		case rparen:
+			if parens == 0 && top {
+				return nil, patternError("", pos, "unexpected %c without opening parenthesis", k)
+			}
+			if parens > 0 {
+				parens--
+			}
+			write(pos, string(k))
+		case caret:
+			if p.syntax.Zsh && len(seg.Nodes) == 0 && !seg.Negate {
+				seg.Negate = true
+				continue
+			}
+			write(pos, string(k))
+		case hash:
+			n := len(seg.Nodes)
+			if !p.syntax.Zsh || n == 0 {
+				write(pos, string(k))
+				continue
+			}
+			rep := Repeat{Node: seg.Nodes[n-1]}
+			if p.peek(hash) {
+				p.ReadRune()
+				rep.Min = 1
+			}
+			if i, ok := rep.Node.(*Literal); ok && atom > i.From {
+				// only the last character of the literal is repeated
+				_, z := utf8.DecodeLastRuneInString(i.Value)
+				rep.Node = &Literal{From: atom, To: i.To, Value: i.Value[len(i.Value)-z:]}
+				i.Value, i.To = i.Value[:len(i.Value)-z], atom
+				n++
+			}
+			rep.From, rep.To = rep.Node.Pos(), p.offset()
+			seg.Nodes = append(seg.Nodes[:n-1], &rep)
+			lit = nil
+		default:
+			write(pos, string(k))
+		}
+	}
+	pat.To = p.offset()
+	segment(pat.To)
+	return &pat, nil
+}
+
+// parseAlts parses the alternatives of a group until its closing character.
+// The opening character has already been read.
+func (p *parser) parseAlts(pos int, sep, end rune) ([]*Pattern, error) {
+	var (
+		alts []*Pattern
+		stop = string([]rune{sep, end})
+	)
+	for {
+		a, err := p.parse(stop)
+		if err != nil {
+			return nil, err
+		}
+		if len(a.Segments) == 0 {
+			a.Segments = append(a.Segments, &Segment{From: a.From, To: a.To})
+		}
+		alts = append(alts, a)
+
+		k, _, err := p.ReadRune()
+		if err != nil {
+			return nil, patternError("", pos, "missing %c to close group", end)
+		}
+		if k == end {
+			return alts, nil
+		}
+	}
+}
+
+// parseClass parses a bracket expression after its opening bracket.
+func (p *parser) parseClass(pos int) (*Class, error) {
+	c := Class{From: pos}
+	k, _, err := p.ReadRune()
+	if err == nil && (k == bang || k == caret) {
+		if strings.ContainsRune(p.syntax.Negate, k) {
+			c.Negate = true
+		} else {
+			c.Ranges = append(c.Ranges, Range{Lo: k, Hi: k})
+		}
+		k, _, err = p.ReadRune()
+	}
+	if err == nil && k == rsquare && p.syntax.Bracket {
+		c.Ranges = append(c.Ranges, Range{Lo: k, Hi: k})
+		k, _, err = p.ReadRune()
+	}
+	for ; err == nil; k, _, err = p.ReadRune() {
+		if k == rsquare {
+			if len(c.Ranges) == 0 {
+				return nil, patternError("", pos, "empty bracket expression")
+			}
+			c.To = p.offset()
+			return &c, nil
+		}
+		at := p.offset() - utf8.RuneLen(k)
+		lo, err := p.classChar(k)
+		if err != nil {
+			return nil, err
+		}
+		rg := Range{Lo: lo, Hi: lo}
+
+		// a dash is a range when it is not the last character of the bracket
+		before := p.offset()
+		if z, _, err := p.ReadRune(); err == nil && z == dash {
+			if z, _, err := p.ReadRune(); err == nil && z != rsquare {
+				if rg.Hi, err = p.classChar(z); err != nil {
+					return nil, err
+				}
+				if rg.Hi < rg.Lo {
+					return nil, patternError("", at, "invalid range %c-%c in bracket expression", rg.Lo, rg.Hi)
+				}
+				before = p.offset()
+			}
+		}
+		p.Seek(int64(before), io.SeekStart)
+		c.Ranges = append(c.Ranges, rg)
+	}
+	return nil, patternError("", pos, "missing %c to close bracket expression", rsquare)
+}
+
+// classChar returns the character k of a bracket expression. An escape
+// character is replaced by the character following it.
+func (p *parser) classChar(k rune) (rune, error) {
+	if k != p.syntax.Escape {
+		return k, nil
+	}
+	pos := p.offset() - utf8.RuneLen(k)
+	z, _, err := p.ReadRune()
+	if err != nil {
+		return 0, patternError("", pos, "trailing escape character")
+	}
+	return z, nil
+}
+
+// isStar reports whether ns is made of two stars only.
+func isStar(ns []Node) bool {
+	return len(ns) == 2 && isWildcard(ns[0], Star) && isWildcard(ns[1], Star)
+}
+
+// peek reports whether the next character is k without consuming it.
+func (p *parser) peek(k rune) bool {
+	z, _, err := p.ReadRune()
+	if err == nil {
+		p.UnreadRune()
+	}
+	return err == nil && z == k
+}
+
+// offset gives the position of the next character to be read.
+func (p *parser) offset() int {
+	return int(p.Size()) - p.Len()
+}
@@ -0,0 +1,161 @@
+package ast
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	data := []struct {
+		Pattern string
+		Syntax  *Syntax
+		Want    string
+	}{
+		{
+			Pattern: "src/**/*.go",
+			Want:    "segment[0:3] literal(src)[0:3] segment[4:6] **[4:6] segment[7:11] *[7:8] literal(.go)[8:11]",
+		},
+		{
+			Pattern: "  a\\*b ",
+			Want:    "segment[2:6] literal(a*b)[2:6]",
+		},
+		{
+			Pattern: "[!a-c_]?",
+			Want:    "segment[0:8] class(!a-c_)[0:7] ?[7:8]",
+		},
+		{
+			Pattern: "*.+(go|c)",
+			Want:    "segment[0:9] *[0:1] literal(.)[1:2] extglob(+)[2:9] pattern[4:6] segment[4:6] literal(go)[4:6] pattern[7:8] segment[7:8] literal(c)[7:8]",
+		},
+		{
+			Pattern: "!(|a/b)",
+			Want:    "segment[0:7] extglob(!)[0:7] pattern[2:2] segment[2:2] pattern[3:6] segment[3:4] literal(a)[3:4] segment[5:6] literal(b)[5:6]",
+		},
+		{
+			Pattern: "(a|b)",
+			Want:    "segment[0:5] literal((a|b))[0:5]",
+		},
+		{
+			Pattern: "{a,b}c",
+			Syntax:  &Syntax{Separators: "/", Braces: true},
+			Want:    "segment[0:6] group({)[0:5] pattern[1:2] segment[1:2] literal(a)[1:2] pattern[3:4] segment[3:4] literal(b)[3:4] literal(c)[5:6]",
+		},
+		{
+			Pattern: "^ab##",
+			Syntax:  &Syntax{Separators: "/", Zsh: true},
+			Want:    "^segment[0:5] literal(a)[1:2] repeat(1)[2:5] literal(b)[2:3]",
+		},
+	}
+	for _, d := range data {
+		var (
+			n   Node
+			err error
+		)
+		if d.Syntax == nil {
+			n, err = Parse(d.Pattern)
+		} else {
+			n, err = ParseRange(d.Pattern, 0, len(d.Pattern), *d.Syntax)
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", d.Pattern, err)
+			continue
+		}
+		if got := dump(n); got != d.Want {
+			t.Errorf("%q: tree mismatched\nwant: %s\ngot:  %s", d.Pattern, d.Want, got)
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	data := []struct {
+		Pattern string
+		Offset  int
+	}{
+		{Pattern: "a/[bc", Offset: 2},
+		{Pattern: "a/[z-a]", Offset: 3},
+		{Pattern: "a/b\\", Offset: 3},
+		{Pattern: "a/b)", Offset: 3},
+		{Pattern: "a/@(b|c", Offset: 2},
+	}
+	for _, d := range data {
+		_, err := Parse(d.Pattern)
+		var e *PatternError
+		if !errors.As(err, &e) {
+			t.Errorf("%q: expected PatternError, got %v", d.Pattern, err)
+			continue
+		}
+		if e.Offset != d.Offset {
+			t.Errorf("%q: offset mismatched: want %d, got %d", d.Pattern, d.Offset, e.Offset)
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	n, err := Parse("a/@(b|[cd])/*.go")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var (
+		enter int
+		leave int
+	)
+	Inspect(n, func(n Node) bool {
+		if n == nil {
+			leave++
+		} else {
+			enter++
+		}
+		_, ok := n.(*ExtGlob)
+		return !ok
+	})
+	if enter != 8 || leave != 7 {
+		t.Errorf("unexpected visits: %d nodes entered, %d left", enter, leave)
+	}
+}
+
+func dump(root Node) string {
+	var parts []string
+	Inspect(root, func(n Node) bool {
+		var str string
+		switch n := n.(type) {
+		case nil, *Pattern:
+			if n == nil || n == root {
+				return true
+			}
+			str = "pattern"
+		case *Segment:
+			str = "segment"
+			if n.Negate {
+				str = "^segment"
+			}
+		case *Literal:
+			str = fmt.Sprintf("literal(%s)", n.Value)
+		case *Wildcard:
+			str = n.Kind.String()
+		case *Class:
+			var buf strings.Builder
+			if n.Negate {
+				buf.WriteRune(bang)
+			}
+			for _, r := range n.Ranges {
+				buf.WriteRune(r.Lo)
+				if r.Hi != r.Lo {
+					buf.WriteRune(dash)
+					buf.WriteRune(r.Hi)
+				}
+			}
+			str = fmt.Sprintf("class(%s)", buf.String())
+		case *ExtGlob:
+			str = fmt.Sprintf("extglob(%c)", n.Kind)
+		case *Group:
+			str = fmt.Sprintf("group(%c)", n.Open)
+		case *Repeat:
+			str = fmt.Sprintf("repeat(%d)", n.Min)
+		}
+		parts = append(parts, fmt.Sprintf("%s[%d:%d]", str, n.Pos(), n.End()))
+		return true
+	})
+	return strings.Join(parts, " ")
+}
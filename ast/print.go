@@ -0,0 +1,119 @@
+package ast
+
+import (
+	"strings"
+)
+
+// QuoteMeta escapes the characters of str having a special meaning in the
+// Extended syntax.
+func QuoteMeta(str string) string {
+	if !strings.ContainsAny(str, `\*?[()|/`) {
+		return str
+	}
+	var buf strings.Builder
+	for _, k := range str {
+		switch k {
+		case backslash, star, mark, lsquare, lparen, rparen, pipe, slash:
+			buf.WriteRune(backslash)
+		}
+		buf.WriteRune(k)
+	}
+	return buf.String()
+}
+
+func (p *Pattern) String() string {
+	parts := make([]string, len(p.Segments))
+	for i, s := range p.Segments {
+		parts[i] = s.String()
+	}
+	return strings.Join(parts, string(slash))
+}
+
+func (s *Segment) String() string {
+	var buf strings.Builder
+	if s.Negate {
+		buf.WriteRune(caret)
+	}
+	for i, n := range s.Nodes {
+		if i > 0 && isWildcard(n, Star) && isWildcard(s.Nodes[i-1], Star) {
+			// multiple stars are the same as one star
+			continue
+		}
+		buf.WriteString(n.String())
+	}
+	return buf.String()
+}
+
+func (i *Literal) String() string {
+	return QuoteMeta(i.Value)
+}
+
+func (w *Wildcard) String() string {
+	return w.Kind.String()
+}
+
+func (c *Class) String() string {
+	var buf strings.Builder
+	buf.WriteRune(lsquare)
+	if c.Negate {
+		buf.WriteRune(bang)
+	}
+	for i, r := range c.Ranges {
+		switch k := r.Lo; {
+		case k == backslash || k == rsquare:
+			buf.WriteRune(backslash)
+		case i == 0 && !c.Negate && (k == bang || k == caret):
+			buf.WriteRune(backslash)
+		case k == dash && i > 0 && i < len(c.Ranges)-1:
+			buf.WriteRune(backslash)
+		}
+		buf.WriteRune(r.Lo)
+		if r.Hi == r.Lo {
+			continue
+		}
+		buf.WriteRune(dash)
+		if r.Hi == backslash || r.Hi == rsquare {
+			buf.WriteRune(backslash)
+		}
+		buf.WriteRune(r.Hi)
+	}
+	buf.WriteRune(rsquare)
+	return buf.String()
+}
+
+func (e *ExtGlob) String() string {
+	return string(e.Kind) + alternatives(e.Alts, pipe, lparen, rparen)
+}
+
+func (g *Group) String() string {
+	if g.Open == lbrace {
+		return alternatives(g.Alts, comma, lbrace, rbrace)
+	}
+	return alternatives(g.Alts, pipe, lparen, rparen)
+}
+
+func (r *Repeat) String() string {
+	str := r.Node.String() + string(hash)
+	if r.Min > 0 {
+		str += string(hash)
+	}
+	return str
+}
+
+func alternatives(alts []*Pattern, sep, open, end rune) string {
+	var buf strings.Builder
+	buf.WriteRune(open)
+	for i, a := range alts {
+		if i > 0 {
+			buf.WriteRune(sep)
+		}
+		buf.WriteString(a.String())
+	}
+	buf.WriteRune(end)
+	return buf.String()
+}
+
+func isWildcard(n Node, kind WildcardKind) bool {
+	w, ok := n.(*Wildcard)
+	return ok && w.Kind == kind
+}
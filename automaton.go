@@ -0,0 +1,678 @@
+package glob
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// automaton matches a segment with a DFA built lazily from the tree of its
+// source matcher. The states of the DFA are the Brzozowski derivatives of the
+// language of the segment: each character of the input costs at most one
+// derivative, computed the first time a transition is taken and cached for
+// the following matches. Matching is then linear in the length of the input
+// whatever the number of wildcards and repetitions in the pattern. The
+// literals required by the pattern are checked first.
+type automaton struct {
+	src  Matcher
+	dfa  *dfa
+	lits literals
+}
+
+func (a *automaton) String() string {
+	return a.src.String()
+}
+
+func (a *automaton) Match(str string) (Matcher, error) {
+	if !a.match(str) {
+		return nil, ErrPattern
+	}
+	return nil, nil
+}
+
+func (a *automaton) match(str string) bool {
+	return !a.lits.reject(str) && a.dfa.match(str)
+}
+
+// automate replaces the matchers of single segments of the tree of m by
+// automata.
+func automate(m Matcher) Matcher {
+	switch x := m.(type) {
+	case *element:
+		ms := appendChain(nil, x)
+		for i := range ms {
+			ms[i] = automate(ms[i])
+		}
+		return linkMatchers(ms)
+	case *group:
+		if a := newAutomaton(x); a != nil {
+			return a
+		}
+		ms := make([]Matcher, len(x.ms))
+		for i := range x.ms {
+			ms[i] = automate(x.ms[i])
+		}
+		return &group{ms: ms}
+	case *simple:
+		if isGlobstar(x) {
+			return x
+		}
+	}
+	if a := newAutomaton(m); a != nil {
+		return a
+	}
+	return m
+}
+
+// newAutomaton returns nil when m can not be converted, because it spans
+// multiple segments or contains matchers defined outside of this package.
+func newAutomaton(m Matcher) *automaton {
+	var (
+		b     = newBuilder()
+		x, ok = b.fromMatcher(m)
+	)
+	if !ok {
+		return nil
+	}
+	return &automaton{
+		src:  m,
+		dfa:  newDFA(b, x),
+		lits: literalsOf(m),
+	}
+}
+
+const (
+	maxStates = 10000
+	// maxExprs bounds the expressions of the builder of a dfa, most of them
+	// being only used by past states.
+	maxExprs = 4 * maxStates
+)
+
+type dfa struct {
+	mu      sync.Mutex
+	builder *builder
+	states  map[*expr]*dstate
+	start   *dstate
+	// gen counts the flushes of the states
+	gen int
+
+	// bounds gives the first character of each class of characters: all the
+	// characters of a class have the same transitions.
+	bounds []rune
+	ascii  [utf8.RuneSelf]int32
+}
+
+type dstate struct {
+	expr   *expr
+	gen    int
+	accept bool
+	dead   bool
+	full   bool
+	next   []atomic.Pointer[dstate]
+}
+
+func newDFA(b *builder, x *expr) *dfa {
+	d := dfa{
+		builder: b,
+		states:  make(map[*expr]*dstate),
+		bounds:  b.classes(x),
+	}
+	for i := range d.ascii {
+		d.ascii[i] = int32(d.classOf(rune(i)))
+	}
+	d.start = d.state(x)
+	return &d
+}
+
+func (d *dfa) match(str string) bool {
+	s := d.start
+	for i := 0; i < len(str); {
+		if s.dead || s.full {
+			break
+		}
+		var (
+			k = rune(str[i])
+			n = 1
+			c int
+		)
+		if k < utf8.RuneSelf {
+			c = int(d.ascii[k])
+		} else {
+			k, n = utf8.DecodeRuneInString(str[i:])
+			c = d.classOf(k)
+		}
+		i += n
+		x := s.next[c].Load()
+		if x == nil {
+			x = d.step(s, c)
+		}
+		s = x
+	}
+	return s.accept
+}
+
+// step computes the transition of s for the class c.
+func (d *dfa) step(s *dstate, c int) *dstate {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if x := s.next[c].Load(); x != nil {
+		return x
+	}
+	if len(d.states) >= maxStates || len(d.builder.exprs) >= maxExprs {
+		d.flush()
+	}
+	if s.gen != d.gen {
+		// s was computed before the last flush
+		s.expr = d.builder.clone(s.expr, make(map[*expr]*expr))
+		s.gen = d.gen
+	}
+	x := d.state(d.builder.derive(s.expr, d.bounds[c]))
+	s.next[c].Store(x)
+	return x
+}
+
+// flush drops the states and the expressions computed so far. Only the start
+// state is kept, its expression being rebuilt by a new builder.
+func (d *dfa) flush() {
+	for _, s := range d.states {
+		for i := range s.next {
+			s.next[i].Store(nil)
+		}
+	}
+	d.gen++
+	d.builder = newBuilder()
+	d.states = make(map[*expr]*dstate)
+	d.start.expr = d.builder.clone(d.start.expr, make(map[*expr]*expr))
+	d.start.gen = d.gen
+	d.states[d.start.expr] = d.start
+}
+
+// state returns the state of x.
+func (d *dfa) state(x *expr) *dstate {
+	if s, ok := d.states[x]; ok {
+		return s
+	}
+	s := dstate{
+		expr:   x,
+		gen:    d.gen,
+		accept: x.nullable,
+		dead:   x == d.builder.none,
+		full:   x == d.builder.all,
+		next:   make([]atomic.Pointer[dstate], len(d.bounds)),
+	}
+	d.states[x] = &s
+	return &s
+}
+
+func (d *dfa) classOf(k rune) int {
+	i := sort.Search(len(d.bounds), func(i int) bool {
+		return d.bounds[i] > k
+	})
+	return i - 1
+}
+
+type exprKind uint8
+
+const (
+	exprNone exprKind = iota // matches nothing
+	exprEps                  // matches the empty string
+	exprSet                  // matches one character of a set
+	exprCat                  // concatenation
+	exprStar                 // zero or more times
+	exprOr                   // union
+	exprAnd                  // intersection
+	exprNot                  // complement
+)
+
+type runeRange struct {
+	lo, hi rune
+}
+
+type expr struct {
+	kind     exprKind
+	id       int
+	set      []runeRange
+	subs     []*expr
+	nullable bool
+}
+
+// builder creates the expressions of an automaton. Expressions are unique:
+// two expressions with the same structure are the same pointer, so that the
+// derivatives of an expression end in a finite number of states.
+type builder struct {
+	exprs map[string]*expr
+	none  *expr
+	eps   *expr
+	all   *expr
+	any   *expr
+}
+
+func newBuilder() *builder {
+	b := builder{
+		exprs: make(map[string]*expr),
+	}
+	b.none = b.intern(&expr{kind: exprNone})
+	b.eps = b.intern(&expr{kind: exprEps, nullable: true})
+	b.any = b.set([]runeRange{{lo: 0, hi: utf8.MaxRune}})
+	b.all = b.not(b.none)
+	return &b
+}
+
+func (b *builder) intern(x *expr) *expr {
+	var key strings.Builder
+	key.WriteByte(byte(x.kind))
+	for _, r := range x.set {
+		key.WriteByte(' ')
+		key.WriteString(strconv.Itoa(int(r.lo)))
+		key.WriteByte('-')
+		key.WriteString(strconv.Itoa(int(r.hi)))
+	}
+	for _, s := range x.subs {
+		key.WriteByte(',')
+		key.WriteString(strconv.Itoa(s.id))
+	}
+	if e, ok := b.exprs[key.String()]; ok {
+		return e
+	}
+	x.id = len(b.exprs)
+	b.exprs[key.String()] = x
+	return x
+}
+
+// clone gives the expression of b with the same structure as x, which may come
+// from another builder.
+func (b *builder) clone(x *expr, seen map[*expr]*expr) *expr {
+	if y, ok := seen[x]; ok {
+		return y
+	}
+	y := expr{
+		kind:     x.kind,
+		set:      x.set,
+		subs:     make([]*expr, len(x.subs)),
+		nullable: x.nullable,
+	}
+	for i, s := range x.subs {
+		y.subs[i] = b.clone(s, seen)
+	}
+	seen[x] = b.intern(&y)
+	return seen[x]
+}
+
+func (b *builder) set(rs []runeRange) *expr {
+	rs = normalizeRanges(rs)
+	if len(rs) == 0 {
+		return b.none
+	}
+	return b.intern(&expr{kind: exprSet, set: rs})
+}
+
+func (b *builder) cat(x, y *expr) *expr {
+	switch {
+	case x == b.none || y == b.none:
+		return b.none
+	case x == b.eps:
+		return y
+	case y == b.eps:
+		return x
+	case x.kind == exprCat:
+		return b.cat(x.subs[0], b.cat(x.subs[1], y))
+	}
+	return b.intern(&expr{
+		kind:     exprCat,
+		subs:     []*expr{x, y},
+		nullable: x.nullable && y.nullable,
+	})
+}
+
+func (b *builder) star(x *expr) *expr {
+	switch {
+	case x == b.none || x == b.eps:
+		return b.eps
+	case x.kind == exprStar:
+		return x
+	case x == b.any:
+		return b.all
+	}
+	return b.intern(&expr{
+		kind:     exprStar,
+		subs:     []*expr{x},
+		nullable: true,
+	})
+}
+
+func (b *builder) not(x *expr) *expr {
+	if x.kind == exprNot {
+		return x.subs[0]
+	}
+	return b.intern(&expr{
+		kind:     exprNot,
+		subs:     []*expr{x},
+		nullable: !x.nullable,
+	})
+}
+
+func (b *builder) or(xs ...*expr) *expr {
+	var list []*expr
+	for _, x := range xs {
+		switch {
+		case x == b.all:
+			return b.all
+		case x == b.none:
+		case x.kind == exprOr:
+			list = append(list, x.subs...)
+		default:
+			list = append(list, x)
+		}
+	}
+	return b.nary(exprOr, list, b.none)
+}
+
+func (b *builder) and(xs ...*expr) *expr {
+	var list []*expr
+	for _, x := range xs {
+		switch {
+		case x == b.none:
+			return b.none
+		case x == b.all:
+		case x.kind == exprAnd:
+			list = append(list, x.subs...)
+		default:
+			list = append(list, x)
+		}
+	}
+	return b.nary(exprAnd, list, b.all)
+}
+
+// nary sorts and removes the duplicates of list before interning it.
+func (b *builder) nary(kind exprKind, list []*expr, zero *expr) *expr {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].id < list[j].id
+	})
+	var (
+		subs     []*expr
+		nullable = kind == exprAnd
+	)
+	for i, x := range list {
+		if i > 0 && list[i-1] == x {
+			continue
+		}
+		subs = append(subs, x)
+		if kind == exprOr {
+			nullable = nullable || x.nullable
+		} else {
+			nullable = nullable && x.nullable
+		}
+	}
+	switch len(subs) {
+	case 0:
+		return zero
+	case 1:
+		return subs[0]
+	}
+	return b.intern(&expr{
+		kind:     kind,
+		subs:     subs,
+		nullable: nullable,
+	})
+}
+
+// repeat gives x repeated at least min and at most max times. A max of zero
+// means no upper bound.
+func (b *builder) repeat(x *expr, min, max int) *expr {
+	var (
+		res  = b.eps
+		tail *expr
+	)
+	if max == 0 {
+		tail = b.star(x)
+	} else {
+		tail = b.eps
+		opt := b.or(b.eps, x)
+		for i := min; i < max; i++ {
+			tail = b.cat(opt, tail)
+		}
+	}
+	for i := 0; i < min; i++ {
+		res = b.cat(res, x)
+	}
+	return b.cat(res, tail)
+}
+
+// derive gives the derivative of x with respect to k: the expression
+// matching the strings s such that x matches k followed by s.
+func (b *builder) derive(x *expr, k rune) *expr {
+	return b.deriveMemo(x, k, make(map[*expr]*expr))
+}
+
+func (b *builder) deriveMemo(x *expr, k rune, memo map[*expr]*expr) *expr {
+	if d, ok := memo[x]; ok {
+		return d
+	}
+	var d *expr
+	switch x.kind {
+	case exprNone, exprEps:
+		d = b.none
+	case exprSet:
+		d = b.none
+		for _, r := range x.set {
+			if k >= r.lo && k <= r.hi {
+				d = b.eps
+				break
+			}
+		}
+	case exprCat:
+		d = b.cat(b.deriveMemo(x.subs[0], k, memo), x.subs[1])
+		if x.subs[0].nullable {
+			d = b.or(d, b.deriveMemo(x.subs[1], k, memo))
+		}
+	case exprStar:
+		d = b.cat(b.deriveMemo(x.subs[0], k, memo), x)
+	case exprNot:
+		d = b.not(b.deriveMemo(x.subs[0], k, memo))
+	case exprOr, exprAnd:
+		ds := make([]*expr, len(x.subs))
+		for i, s := range x.subs {
+			ds[i] = b.deriveMemo(s, k, memo)
+		}
+		if x.kind == exprOr {
+			d = b.or(ds...)
+		} else {
+			d = b.and(ds...)
+		}
+	}
+	memo[x] = d
+	return d
+}
+
+// classes gives the first character of each class of characters for which
+// all the expressions reachable from x behave the same.
+func (b *builder) classes(x *expr) []rune {
+	var (
+		seen   = make(map[*expr]bool)
+		bounds = map[rune]bool{0: true}
+		walk   func(*expr)
+	)
+	walk = func(x *expr) {
+		if seen[x] {
+			return
+		}
+		seen[x] = true
+		for _, r := range x.set {
+			bounds[r.lo] = true
+			if r.hi < utf8.MaxRune {
+				bounds[r.hi+1] = true
+			}
+		}
+		for _, s := range x.subs {
+			walk(s)
+		}
+	}
+	walk(x)
+
+	list := make([]rune, 0, len(bounds))
+	for k := range bounds {
+		list = append(list, k)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i] < list[j]
+	})
+	return list
+}
+
+// fromMatcher converts the tree of m to an expression. It reports false when
+// m contains a matcher defined outside of this package or spanning multiple
+// segments, like a ** in an alternative.
+func (b *builder) fromMatcher(m Matcher) (*expr, bool) {
+	switch m := m.(type) {
+	case *simple:
+		if isGlobstar(m) {
+			return nil, false
+		}
+		return b.fromPattern(m.pattern), true
+	case *automaton:
+		return b.fromMatcher(m.src)
+	case *multiple:
+		x := b.eps
+		for _, m := range m.ms {
+			y, ok := b.fromMatcher(m)
+			if !ok {
+				return nil, false
+			}
+			x = b.cat(x, y)
+		}
+		return x, true
+	case *group:
+		xs := make([]*expr, 0, len(m.ms))
+		for _, m := range m.ms {
+			x, ok := b.fromMatcher(m)
+			if !ok {
+				return nil, false
+			}
+			xs = append(xs, x)
+		}
+		return b.or(xs...), true
+	case *any:
+		x, ok := b.fromMatcher(m.inner)
+		if !ok {
+			return nil, false
+		}
+		return b.repeat(x, m.min, m.max), true
+	case *not:
+		x, ok := b.fromMatcher(m.inner)
+		if !ok {
+			return nil, false
+		}
+		return b.not(x), true
+	case *visible:
+		x, ok := b.fromMatcher(m.inner)
+		if !ok {
+			return nil, false
+		}
+		dot := b.cat(b.set([]runeRange{{lo: '.', hi: '.'}}), b.all)
+		return b.and(x, b.not(dot)), true
+	case *element:
+		if m.next != nil {
+			return nil, false
+		}
+		return b.fromMatcher(m.head)
+	default:
+		return nil, false
+	}
+}
+
+// fromPattern converts the pattern of a simple matcher to an expression.
+func (b *builder) fromPattern(pat string) *expr {
+	x := b.eps
+	for i := 0; i < len(pat); {
+		k, n := utf8.DecodeRuneInString(pat[i:])
+		var y *expr
+		switch {
+		case k == star:
+			y = b.all
+		case k == mark:
+			y = b.any
+		case k == lsquare && classSize(pat[i:]) > 0:
+			n = classSize(pat[i:])
+			y = b.set(classRanges(pat[i+1 : i+n-1]))
+		case k == backslash && i+n < len(pat):
+			k, z := utf8.DecodeRuneInString(pat[i+n:])
+			n += z
+			y = b.set([]runeRange{{lo: k, hi: k}})
+		default:
+			y = b.set([]runeRange{{lo: k, hi: k}})
+		}
+		x = b.cat(x, y)
+		i += n
+	}
+	return x
+}
+
+// classRanges gives the characters matched by the content of a bracket
+// expression following the rules of charsetMatch.
+func classRanges(pat string) []runeRange {
+	var (
+		rs     []runeRange
+		negate bool
+	)
+	if k, n := utf8.DecodeRuneInString(pat); k == bang || k == caret {
+		negate = true
+		pat = pat[n:]
+	}
+	for i := 0; i < len(pat); {
+		lo, n, _ := classRune(pat[i:])
+		i += n
+		hi := lo
+		if k, n := utf8.DecodeRuneInString(pat[i:]); k == dash && i+n < len(pat) {
+			k, z, _ := classRune(pat[i+n:])
+			hi = k
+			i += n + z
+		}
+		if lo <= hi {
+			rs = append(rs, runeRange{lo: lo, hi: hi})
+		}
+	}
+	rs = normalizeRanges(rs)
+	if negate {
+		rs = negateRanges(rs)
+	}
+	return rs
+}
+
+// normalizeRanges sorts rs and merges its overlapping ranges.
+func normalizeRanges(rs []runeRange) []runeRange {
+	sort.Slice(rs, func(i, j int) bool {
+		return rs[i].lo < rs[j].lo
+	})
+	var list []runeRange
+	for _, r := range rs {
+		if n := len(list); n > 0 && r.lo <= list[n-1].hi+1 {
+			if r.hi > list[n-1].hi {
+				list[n-1].hi = r.hi
+			}
+			continue
+		}
+		list = append(list, r)
+	}
+	return list
+}
+
+// negateRanges gives the complement of the normalized ranges rs.
+func negateRanges(rs []runeRange) []runeRange {
+	var (
+		list []runeRange
+		lo   rune
+	)
+	for _, r := range rs {
+		if r.lo > lo {
+			list = append(list, runeRange{lo: lo, hi: r.lo - 1})
+		}
+		lo = r.hi + 1
+	}
+	if lo <= utf8.MaxRune {
+		list = append(list, runeRange{lo: lo, hi: utf8.MaxRune})
+	}
+	return list
+}
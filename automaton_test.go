@@ -0,0 +1,215 @@
+package glob
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAutomaton(t *testing.T) {
+	patterns := []string{
+		"*a*b",
+		"a?c",
+		"[a-c]*",
+		"[!a]b",
+		"*(a|ab)c",
+		"+(a|b)c",
+		"?(a)b",
+		"!(a|b)",
+		"!(a*)b",
+		"a!(b)c",
+		"@(a|b*)",
+		"*.!(c)",
+		"a*(b|c)a",
+		"+(ab|a)",
+		"!(*a)",
+		`a\*b`,
+		"*(*a)",
+		"ab*ca",
+		"*.@(ab|cb)",
+		"a*bc*",
+		"+(ab)c",
+		"*(ab|a)c.",
+	}
+	r := rand.New(rand.NewSource(1))
+	for _, p := range patterns {
+		m, err := Compile(p)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", p, err)
+			continue
+		}
+		if _, ok := unwrap(m).(*automaton); !ok {
+			t.Errorf("%q: pattern not compiled to an automaton", p)
+			continue
+		}
+		// the automaton should agree with the tree of matchers it is built from
+		raw, _ := Extended.build(p, configure(nil))
+		for i := 0; i < 1000; i++ {
+			var buf strings.Builder
+			for j, n := 0, r.Intn(7); j < n; j++ {
+				buf.WriteByte("abc.*"[r.Intn(5)])
+			}
+			var (
+				str  = buf.String()
+				want = MatchWith(str, raw) == nil
+			)
+			if got := MatchWith(str, m) == nil; got != want {
+				t.Errorf("%q: %q should match: %t", p, str, want)
+				break
+			}
+		}
+	}
+}
+
+func TestAutomatonSegments(t *testing.T) {
+	patterns := []string{
+		"+(**)",
+		"@(**)",
+		"!(**|ab)",
+		"!(**|bb?b|*(ba./))a",
+		"+(a|**/b)",
+		"*(a/b)",
+		"@(a|b/c)x",
+		"*(ba./)",
+	}
+	r := rand.New(rand.NewSource(1))
+	for _, p := range patterns {
+		m, err := Compile(p)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", p, err)
+			continue
+		}
+		// the paths are made of several segments: the alternatives spanning
+		// them are kept out of the automata
+		raw, _ := Extended.build(p, configure(nil))
+		for i := 0; i < 1000; i++ {
+			var buf strings.Builder
+			for j, n := 0, r.Intn(7); j < n; j++ {
+				buf.WriteByte("ab./"[r.Intn(4)])
+			}
+			var (
+				str  = buf.String()
+				want = MatchWith(str, raw) == nil
+			)
+			if got := MatchWith(str, m) == nil; got != want {
+				t.Errorf("%q: %q should match: %t", p, str, want)
+				break
+			}
+		}
+	}
+}
+
+func TestAutomatonLinear(t *testing.T) {
+	data := []struct {
+		Pattern string
+		Input   string
+	}{
+		{Pattern: "*(a|aa)*(a|aa)b", Input: strings.Repeat("a", 10000)},
+		{Pattern: "*a*a*a*a*a*a*a*a*b", Input: strings.Repeat("a", 10000)},
+		{Pattern: "+(+(a))b", Input: strings.Repeat("a", 10000)},
+		{Pattern: "!(*(a|aa))", Input: strings.Repeat("a", 10000)},
+	}
+	for _, d := range data {
+		now := time.Now()
+		if err := Match(d.Input, d.Pattern); err == nil {
+			t.Errorf("%q: unexpected match", d.Pattern)
+		}
+		if elapsed := time.Since(now); elapsed > time.Second {
+			t.Errorf("%q: match took %s", d.Pattern, elapsed)
+		}
+	}
+}
+
+func TestAutomatonMemory(t *testing.T) {
+	var (
+		r   = rand.New(rand.NewSource(1))
+		buf strings.Builder
+	)
+	for i := 0; i < 100000; i++ {
+		buf.WriteByte("ab"[r.Intn(2)])
+	}
+	var (
+		str     = buf.String()
+		pattern = "*a" + strings.Repeat("?", 20)
+	)
+	m, err := Compile(pattern)
+	if err != nil {
+		t.Fatalf("%q: unexpected error: %s", pattern, err)
+	}
+	a, ok := unwrap(m).(*automaton)
+	if !ok {
+		t.Fatalf("%q: pattern not compiled to an automaton", pattern)
+	}
+	// the states exceed the limit of the cache
+	want := str[len(str)-21] == 'a'
+	if got := a.match(str); got != want {
+		t.Errorf("%q: input should match: %t", pattern, want)
+	}
+	if n := len(a.dfa.builder.exprs); n > maxExprs {
+		t.Errorf("%q: %d expressions kept", pattern, n)
+	}
+}
+
+func TestConcurrentMatch(t *testing.T) {
+	var (
+		patterns = []string{
+			"src/**/*.@(go|c)",
+			"*(a|aa)*(a|aa)b",
+			"**/!(*_test).go",
+			"+(ab|a)?(c)",
+			"[!.]*/**/*.+(tar|gz)",
+		}
+		inputs = []string{
+			"src/main.go",
+			"src/a/b/c/main.c",
+			"aaaaaaab",
+			"aaaaaaaa",
+			"pkg/glob/match_test.go",
+			"pkg/glob/match.go",
+			"ababac",
+			"archive/2020/backup.tar.gz",
+			".cache/backup.tar",
+		}
+		ms   = make([]Matcher, len(patterns))
+		want = make([][]bool, len(patterns))
+	)
+	for i, p := range patterns {
+		m, err := Compile(p)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", p, err)
+		}
+		ms[i] = m
+		for _, str := range inputs {
+			want[i] = append(want[i], MatchWith(str, m) == nil)
+		}
+	}
+	// the same matchers are fresh again for the goroutines: their caches
+	// are filled concurrently
+	for i, p := range patterns {
+		ms[i], _ = Compile(p)
+	}
+	var (
+		workers = 16
+		done    = make(chan struct{})
+	)
+	for w := 0; w < workers; w++ {
+		go func(w int) {
+			defer func() {
+				done <- struct{}{}
+			}()
+			for n := 0; n < 200; n++ {
+				i := (w + n) % len(ms)
+				for j, str := range inputs {
+					if got := MatchWith(str, ms[i]) == nil; got != want[i][j] {
+						t.Errorf("%q: %q should match: %t", patterns[i], str, want[i][j])
+						return
+					}
+				}
+			}
+		}(w)
+	}
+	for w := 0; w < workers; w++ {
+		<-done
+	}
+}
@@ -21,8 +21,10 @@ func main() {
 	var (
 		matching  = flag.Bool("m", false, "matching")
 		compiling = flag.Bool("c", false, "compiling")
+		linting   = flag.Bool("l", false, "linting")
 		fast      = flag.Bool("f", false, "fast")
 		csv       = flag.Bool("p", false, "csv")
+		dialect   = flag.String("d", "extended", "dialect used by linting")
 	)
 	flag.Parse()
 
@@ -32,6 +34,8 @@ func main() {
 		err = runCompile(flag.Args())
 	case *matching:
 		err = runMatch(flag.Args())
+	case *linting:
+		err = runLint(*dialect, flag.Args())
 	default:
 		args := make([]string, flag.NArg()-1)
 		for i := 1; i < flag.NArg(); i++ {
@@ -55,6 +59,38 @@ func runMatch(args []string) error {
 	return nil
 }
 
+func runLint(dialect string, args []string) error {
+	dia, err := parseDialect(dialect)
+	if err != nil {
+		return err
+	}
+	var count int
+	for _, a := range args {
+		for _, d := range glob.Lint(a, glob.WithDialect(dia)) {
+			fmt.Println(d.Caret())
+			if d.Error {
+				fmt.Println("error:", d.Msg)
+			} else {
+				fmt.Printf("warning: %s (use %s)\n", d.Msg, d.Fixed())
+			}
+			count++
+		}
+	}
+	if count > 0 {
+		return fmt.Errorf("%d problem(s) found", count)
+	}
+	return nil
+}
+
+func parseDialect(name string) (glob.Dialect, error) {
+	for d := glob.Extended; d <= glob.Doublestar; d++ {
+		if d.String() == strings.ToLower(name) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("%s: unknown dialect", name)
+}
+
 func runCompile(args []string) error {
 	for i := 0; i < len(args); i++ {
 		if i > 0 {
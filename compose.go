@@ -0,0 +1,176 @@
+package glob
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Seq returns a Matcher for the paths made of the segments matched by each
+// of ms in turn. The matchers can span multiple segments, like the ones
+// returned by Compile.
+func Seq(ms ...Matcher) Matcher {
+	var xs []Matcher
+	for _, m := range ms {
+		xs = appendChain(xs, m)
+	}
+	return linkMatchers(xs)
+}
+
+// Or returns a Matcher for the paths matched by at least one of ms.
+//
+// Like the ones built by Seq, And and Not, the returned Matcher can be given
+// to MatchWith and NewMatcher.
+func Or(ms ...Matcher) Matcher {
+	if len(ms) == 1 {
+		return ms[0]
+	}
+	xs := make([]Matcher, 0, len(ms))
+	for _, m := range ms {
+		if m != nil {
+			xs = append(xs, m)
+		}
+	}
+	return &group{ms: xs}
+}
+
+// Not returns a Matcher for the paths not matched by m. Since the paths
+// can have any number of segments, a path is also matched once m can not
+// match it anymore whatever the following segments are.
+func Not(m Matcher) Matcher {
+	return &complement{inner: m}
+}
+
+// And returns a Matcher for the paths matched by all of ms.
+func And(ms ...Matcher) Matcher {
+	if len(ms) == 1 {
+		return ms[0]
+	}
+	xs := make([]Matcher, len(ms))
+	copy(xs, ms)
+	return &intersect{ms: xs}
+}
+
+// Repeat returns a Matcher for the segments made of at least min and at most
+// max consecutive strings matched by m. A max of zero means no upper bound.
+func Repeat(m Matcher, min, max int) Matcher {
+	return &any{
+		min:   min,
+		max:   max,
+		inner: m,
+	}
+}
+
+// appendChain appends the heads of the elements of m to ms.
+func appendChain(ms []Matcher, m Matcher) []Matcher {
+	for m != nil {
+		e, ok := m.(*element)
+		if !ok {
+			return append(ms, m)
+		}
+		ms = append(ms, e.head)
+		m = e.next
+	}
+	return ms
+}
+
+// complement matches the paths rejected by its inner matcher.
+type complement struct {
+	inner Matcher
+}
+
+// String describes c with the call to Not building it since the Extended
+// syntax can only negate a segment, not a whole path.
+func (c *complement) String() string {
+	return "Not(" + describe(c.inner) + ")"
+}
+
+func (c *complement) Match(str string) (Matcher, error) {
+	next, done := step(c.inner, str)
+	if next == nil {
+		// inner is exhausted: every longer path is rejected by it
+		next = everything()
+	} else {
+		next = &complement{inner: next}
+	}
+	if done {
+		return next, nil
+	}
+	return next, ErrMatch
+}
+
+// describe gives the quoted pattern of m or the description of the
+// combination of patterns it is.
+func describe(m Matcher) string {
+	switch m.(type) {
+	case *complement, *intersect:
+		return m.String()
+	default:
+		return strconv.Quote(m.String())
+	}
+}
+
+// intersect matches the paths accepted by all its matchers.
+type intersect struct {
+	ms []Matcher
+}
+
+// String describes i with the call to And building it since the Extended
+// syntax has no intersection of patterns.
+func (i *intersect) String() string {
+	var buf strings.Builder
+	buf.WriteString("And(")
+	for j, m := range i.ms {
+		if j > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(describe(m))
+	}
+	buf.WriteRune(rparen)
+	return buf.String()
+}
+
+func (i *intersect) Match(str string) (Matcher, error) {
+	var (
+		next = make([]Matcher, 0, len(i.ms))
+		done = true
+		over bool
+	)
+	for _, m := range i.ms {
+		x, ok := step(m, str)
+		if x == nil && !ok {
+			return nil, ErrPattern
+		}
+		done = done && ok
+		over = over || x == nil
+		next = append(next, x)
+	}
+	switch {
+	case over && done:
+		return nil, nil
+	case over:
+		return nil, ErrPattern
+	case done:
+		return &intersect{ms: next}, ErrMatch
+	default:
+		return &intersect{ms: next}, nil
+	}
+}
+
+// step feeds m with str. It returns the matcher for the following segments,
+// if any, and reports whether the path is accepted after str.
+func step(m Matcher, str string) (Matcher, bool) {
+	if m == nil {
+		return nil, false
+	}
+	next, err := m.Match(str)
+	if err != nil && !errors.Is(err, ErrMatch) {
+		return nil, false
+	}
+	return next, next == nil || errors.Is(err, ErrMatch)
+}
+
+// everything returns a Matcher accepting any sequence of segments.
+func everything() Matcher {
+	return linkMatchers([]Matcher{&simple{pattern: "**"}})
+}
@@ -0,0 +1,344 @@
+package glob
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/midbel/glob/ast"
+)
+
+// Dialect selects the syntax used by Compile to parse a pattern and the way
+// Match splits its input into segments.
+type Dialect int
+
+const (
+	// Extended is the default dialect: slash separated segments with support
+	// for **, character classes and the extended patterns of bash extglob.
+	// Wildcards match a leading dot, [!...] and [^...] are both negated
+	// classes, (a|b) without prefix is literal text and a backslash followed
+	// by a newline continues the pattern on the next line.
+	Extended Dialect = iota
+	// MQTT parses MQTT topic filters where + matches one level and # matches
+	// any number of trailing levels.
+	MQTT
+	// AMQP parses binding keys of topic exchanges where * matches one word
+	// and # matches zero or more words.
+	AMQP
+	// Hostname parses DNS names where a * as the left-most label matches
+	// exactly one label. Names are compared without regard to case.
+	Hostname
+	// GoPackage parses package patterns as accepted by go list where ...
+	// matches any string, including slashes.
+	GoPackage
+	// Windows parses Windows paths where both backslash and slash separate
+	// segments. Backtick is the default escape character and paths are
+	// compared without regard to case.
+	Windows
+	// FilepathMatch follows path/filepath.Match: no extended patterns, ** is
+	// the same as *, only [^...] is a negated class and leading or trailing
+	// slashes are significant.
+	FilepathMatch
+	// Bash follows bash with the extglob, globstar and dotglob options set.
+	Bash
+	// Zsh follows zsh with the EXTENDED_GLOB option set: (a|b) groups, ^
+	// negates a whole segment, # and ## repeat the previous character, class
+	// or group zero/one or more times and only **/ matches zero or more
+	// directories. Wildcards never match a leading dot.
+	Zsh
+	// Doublestar follows the github.com/bmatcuk/doublestar library: {a,b}
+	// alternatives, ** matches zero or more directories and no extended
+	// patterns.
+	Doublestar
+)
+
+func (d Dialect) String() string {
+	switch d {
+	case Extended:
+		return "extended"
+	case MQTT:
+		return "mqtt"
+	case AMQP:
+		return "amqp"
+	case Hostname:
+		return "hostname"
+	case GoPackage:
+		return "gopackage"
+	case Windows:
+		return "windows"
+	case FilepathMatch:
+		return "filepath"
+	case Bash:
+		return "bash"
+	case Zsh:
+		return "zsh"
+	case Doublestar:
+		return "doublestar"
+	default:
+		return "unknown"
+	}
+}
+
+func (d Dialect) compile(pattern string, cfg *config) (Matcher, error) {
+	m, err := d.build(pattern, cfg)
+	if err != nil {
+		return nil, err
+	}
+	m = automate(optimize(m))
+	if cfg.strict && IsEmpty(m) {
+		return nil, patternError(pattern, 0, "%s: pattern never matches", d)
+	}
+	return m, nil
+}
+
+// build gives the raw tree of pattern. A pattern made only of separators
+// is reported as empty.
+func (d Dialect) build(pattern string, cfg *config) (Matcher, error) {
+	m, err := d.parse(pattern, cfg)
+	if err == nil && m == nil {
+		err = patternError(pattern, 0, "empty pattern")
+	}
+	return m, err
+}
+
+func (d Dialect) parse(pattern string, cfg *config) (Matcher, error) {
+	switch d {
+	case Extended:
+		return compileExtended(pattern, cfg)
+	case MQTT:
+		return compileMQTT(pattern, cfg)
+	case AMQP:
+		return compileAMQP(pattern, cfg)
+	case Hostname:
+		return compileHostname(pattern, cfg)
+	case GoPackage:
+		return compileGoPackage(pattern, cfg)
+	case Windows:
+		return compileWindows(pattern, cfg)
+	case FilepathMatch:
+		return compileFilepath(pattern, cfg)
+	case Bash:
+		return compileSyntax(pattern, cfg.syntax(bashSyntax))
+	case Zsh:
+		return compileSyntax(pattern, cfg.syntax(zshSyntax))
+	case Doublestar:
+		return compileSyntax(pattern, cfg.syntax(doublestarSyntax))
+	default:
+		return nil, fmt.Errorf("unknown dialect %d", d)
+	}
+}
+
+func (d Dialect) split(str string, cfg *config) []string {
+	switch d {
+	case MQTT:
+		return strings.Split(str, "/")
+	case AMQP:
+		return strings.Split(str, ".")
+	case Hostname:
+		return strings.Split(normalizeHost(str, cfg.idna), ".")
+	case Windows:
+		return splitWindows(str)
+	case FilepathMatch:
+		return strings.Split(str, "/")
+	default:
+		return strings.Split(strings.Trim(str, "/"), "/")
+	}
+}
+
+// Option configures how Compile and Match handle a pattern.
+type Option func(*config)
+
+// WithDialect selects the dialect of the pattern. Extended is used when no
+// dialect is given.
+func WithDialect(d Dialect) Option {
+	return func(c *config) {
+		c.dialect = d
+	}
+}
+
+// WithIDNA converts the internationalized labels of hostnames to their ASCII
+// form (punycode) before comparing them.
+func WithIDNA() Option {
+	return func(c *config) {
+		c.idna = true
+	}
+}
+
+// WithEscape changes the character used to escape the special characters of
+// a pattern in the dialects supporting it.
+func WithEscape(k rune) Option {
+	return func(c *config) {
+		c.escape = k
+	}
+}
+
+// WithStrict makes Compile reject the patterns that can never match, like
+// *.go!(*), instead of returning a Matcher that rejects every path.
+func WithStrict() Option {
+	return func(c *config) {
+		c.strict = true
+	}
+}
+
+// WithRelative tells Lint that the pattern is walked relative to the
+// directories given to New, where a leading separator is ignored.
+func WithRelative() Option {
+	return func(c *config) {
+		c.relative = true
+	}
+}
+
+type config struct {
+	dialect  Dialect
+	idna     bool
+	escape   rune
+	strict   bool
+	relative bool
+}
+
+// separators gives the separators of the dialect, the first one joining the
+// segments of a path.
+func (c *config) separators() string {
+	switch c.dialect {
+	case AMQP, Hostname:
+		return "."
+	case Windows:
+		return winSeparators
+	default:
+		return string(slash)
+	}
+}
+
+// configureFor is configure but, without options, it uses the configuration
+// of the first Pattern in ms.
+func configureFor(opts []Option, ms ...Matcher) *config {
+	if len(opts) == 0 {
+		for _, m := range ms {
+			if p, ok := m.(*Pattern); ok {
+				return p.cfg
+			}
+		}
+	}
+	return configure(opts)
+}
+
+// syntax returns a copy of the syntax of a dialect where the escape character
+// is replaced by the one given with WithEscape.
+func (c *config) syntax(base syntax) *syntax {
+	if c.escape != 0 {
+		base.Escape = c.escape
+	}
+	return &base
+}
+
+// syntax describes the features supported by the parser for a dialect.
+type syntax struct {
+	ast.Syntax
+	// wildcards match a leading dot
+	dotglob bool
+}
+
+var (
+	extendedSyntax = syntax{
+		Syntax:  ast.Extended,
+		dotglob: true,
+	}
+	windowsSyntax = syntax{
+		Syntax: ast.Syntax{
+			Escape:     '`',
+			Separators: winSeparators,
+			Negate:     string([]rune{bang, caret}),
+			ExtGlob:    true,
+			Globstar:   true,
+		},
+		dotglob: true,
+	}
+	filepathSyntax = syntax{
+		Syntax: ast.Syntax{
+			Escape: backslash,
+			Negate: string(caret),
+		},
+		dotglob: true,
+	}
+	bashSyntax = syntax{
+		Syntax: ast.Syntax{
+			Escape:     backslash,
+			Separators: string(slash),
+			Negate:     string([]rune{bang, caret}),
+			ExtGlob:    true,
+			Globstar:   true,
+			Bracket:    true,
+		},
+		dotglob: true,
+	}
+	zshSyntax = syntax{
+		Syntax: ast.Syntax{
+			Escape:     backslash,
+			Separators: string(slash),
+			Negate:     string([]rune{bang, caret}),
+			Globstar:   true,
+			Groups:     true,
+			Zsh:        true,
+			Bracket:    true,
+		},
+	}
+	doublestarSyntax = syntax{
+		Syntax: ast.Syntax{
+			Escape:     backslash,
+			Separators: string(slash),
+			Negate:     string([]rune{bang, caret}),
+			Globstar:   true,
+			Braces:     true,
+			Bracket:    true,
+		},
+		dotglob: true,
+	}
+)
+
+// compileFilepath compiles each segment of pattern on its own since, unlike
+// the other dialects, empty segments are significant.
+func compileFilepath(pattern string, cfg *config) (Matcher, error) {
+	var (
+		syn    = cfg.syntax(filepathSyntax)
+		parts  = strings.Split(pattern, string(slash))
+		ms     = make([]Matcher, 0, len(parts))
+		offset int
+	)
+	for _, p := range parts {
+		t, err := ast.ParseRange(pattern, offset, offset+len(p), syn.Syntax)
+		if err != nil {
+			return nil, err
+		}
+		offset += len(p) + 1
+		if len(t.Segments) == 0 {
+			ms = append(ms, &simple{})
+			continue
+		}
+		ms = append(ms, compileSegment(t.Segments[0], syn))
+	}
+	return linkMatchers(ms), nil
+}
+
+func configure(opts []Option) *config {
+	var cfg config
+	for _, o := range opts {
+		o(&cfg)
+	}
+	return &cfg
+}
+
+// quote escapes the characters having a special meaning in the pattern of a
+// simple matcher.
+func quote(str string) string {
+	if !strings.ContainsAny(str, `\*?[`) {
+		return str
+	}
+	var buf strings.Builder
+	for _, k := range str {
+		switch k {
+		case backslash, star, mark, lsquare:
+			buf.WriteRune(backslash)
+		}
+		buf.WriteRune(k)
+	}
+	return buf.String()
+}
@@ -0,0 +1,312 @@
+package glob
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDialect(t *testing.T) {
+	t.Run("mqtt", testDialectMQTT)
+	t.Run("amqp", testDialectAMQP)
+	t.Run("hostname", testDialectHostname)
+	t.Run("gopackage", testDialectGoPackage)
+	t.Run("windows", testDialectWindows)
+	t.Run("filepath", testDialectFilepath)
+	t.Run("bash", testDialectBash)
+	t.Run("zsh", testDialectZsh)
+	t.Run("doublestar", testDialectDoublestar)
+}
+
+func testDialectMQTT(t *testing.T) {
+	data := []MatchCase{
+		{Pattern: "sport/tennis/player1", Input: "sport/tennis/player1", Match: true},
+		{Pattern: "sport/tennis/player1", Input: "sport/tennis/player2", Match: false},
+		{Pattern: "sport/tennis/player1/#", Input: "sport/tennis/player1", Match: true},
+		{Pattern: "sport/tennis/player1/#", Input: "sport/tennis/player1/ranking", Match: true},
+		{Pattern: "sport/tennis/player1/#", Input: "sport/tennis/player1/score/wimbledon", Match: true},
+		{Pattern: "sport/tennis/player1/#", Input: "sport/tennis/player2", Match: false},
+		{Pattern: "sport/#", Input: "sport", Match: true},
+		{Pattern: "#", Input: "sport/tennis", Match: true},
+		{Pattern: "#", Input: "/finance", Match: true},
+		{Pattern: "sport/tennis/+", Input: "sport/tennis/player1", Match: true},
+		{Pattern: "sport/tennis/+", Input: "sport/tennis/player1/ranking", Match: false},
+		{Pattern: "sport/+", Input: "sport", Match: false},
+		{Pattern: "sport/+", Input: "sport/", Match: true},
+		{Pattern: "+/+", Input: "/finance", Match: true},
+		{Pattern: "/+", Input: "/finance", Match: true},
+		{Pattern: "+", Input: "/finance", Match: false},
+		{Pattern: "+/tennis/#", Input: "sport/tennis/player1", Match: true},
+		{Pattern: "sensors/+/temperature", Input: "sensors/kitchen/temperature", Match: true},
+		{Pattern: "sensors/+/temperature", Input: "sensors/kitchen/humidity", Match: false},
+		{Pattern: "sensors/*/temperature", Input: "sensors/kitchen/temperature", Match: false},
+		{Pattern: "sensors/*/temperature", Input: "sensors/*/temperature", Match: true},
+		{Pattern: "#", Input: "$SYS/broker/clients", Match: false},
+		{Pattern: "+/monitor/clients", Input: "$SYS/monitor/clients", Match: false},
+		{Pattern: "$SYS/#", Input: "$SYS/broker/clients", Match: true},
+		{Pattern: "$SYS/monitor/+", Input: "$SYS/monitor/clients", Match: true},
+	}
+	testDialectCases(t, MQTT, data)
+
+	for _, p := range []string{"", "sport/tennis#", "sport/tennis/#/ranking", "sport+", "sport/+tennis", "#/a"} {
+		if _, err := Compile(p, WithDialect(MQTT)); err == nil {
+			t.Errorf("%q: invalid topic filter compiled", p)
+		}
+	}
+}
+
+func testDialectAMQP(t *testing.T) {
+	data := []MatchCase{
+		{Pattern: "*.orange.*", Input: "quick.orange.rabbit", Match: true},
+		{Pattern: "*.orange.*", Input: "quick.orange.male.rabbit", Match: false},
+		{Pattern: "*.orange.*", Input: "orange", Match: false},
+		{Pattern: "*.*.rabbit", Input: "lazy.orange.rabbit", Match: true},
+		{Pattern: "*.*.rabbit", Input: "quick.brown.fox", Match: false},
+		{Pattern: "lazy.#", Input: "lazy", Match: true},
+		{Pattern: "lazy.#", Input: "lazy.orange.male.rabbit", Match: true},
+		{Pattern: "lazy.#", Input: "quick.lazy", Match: false},
+		{Pattern: "#", Input: "", Match: true},
+		{Pattern: "#", Input: "quick.orange.rabbit", Match: true},
+		{Pattern: "#.rabbit", Input: "rabbit", Match: true},
+		{Pattern: "#.rabbit", Input: "quick.orange.rabbit", Match: true},
+		{Pattern: "#.rabbit", Input: "quick.orange.rabbit.fox", Match: false},
+		{Pattern: "a.#.b", Input: "a.b", Match: true},
+		{Pattern: "a.#.b", Input: "a.b.b", Match: true},
+		{Pattern: "a.#.b", Input: "a.x.y.b", Match: true},
+		{Pattern: "a.#.b", Input: "a.x.y", Match: false},
+		{Pattern: "#.#.b", Input: "b", Match: true},
+		{Pattern: "a.#.*", Input: "a", Match: false},
+		{Pattern: "a.#.*", Input: "a.b.c", Match: true},
+		{Pattern: "a*.b", Input: "abc.b", Match: false},
+		{Pattern: "a*.b", Input: "a*.b", Match: true},
+		{Pattern: "", Input: "", Match: true},
+		{Pattern: "", Input: "a", Match: false},
+	}
+	testDialectCases(t, AMQP, data)
+
+	if _, err := Compile(strings.Repeat("a", 256), WithDialect(AMQP)); err == nil {
+		t.Errorf("too long binding key compiled")
+	}
+}
+
+func testDialectHostname(t *testing.T) {
+	data := []MatchCase{
+		{Pattern: "www.example.com", Input: "www.example.com", Match: true},
+		{Pattern: "www.example.com", Input: "WWW.Example.COM", Match: true},
+		{Pattern: "www.example.com", Input: "www.example.com.", Match: true},
+		{Pattern: "*.example.com", Input: "foo.example.com", Match: true},
+		{Pattern: "*.example.com", Input: "FOO.EXAMPLE.COM", Match: true},
+		{Pattern: "*.Example.com", Input: "foo.example.com", Match: true},
+		{Pattern: "*.example.com", Input: "example.com", Match: false},
+		{Pattern: "*.example.com", Input: "a.b.example.com", Match: false},
+		{Pattern: "*.example.com", Input: ".example.com", Match: false},
+		{Pattern: "*.example.com", Input: "foo.example.org", Match: false},
+		{Pattern: "api.*.com", Input: "api.example.com", Match: false},
+	}
+	testDialectCases(t, Hostname, data)
+
+	for _, p := range []string{"", "*", "*.com", "a.*.com", "w*.example.com", "**.example.com", "a..com", "ex ample.com", "bücher.example"} {
+		if _, err := Compile(p, WithDialect(Hostname)); err == nil {
+			t.Errorf("%q: invalid hostname compiled", p)
+		}
+	}
+
+	idna := []MatchCase{
+		{Pattern: "*.bücher.example", Input: "shop.xn--bcher-kva.example", Match: true},
+		{Pattern: "*.xn--bcher-kva.example", Input: "shop.Bücher.example", Match: true},
+		{Pattern: "münchen.de", Input: "xn--mnchen-3ya.de", Match: true},
+		{Pattern: "münchen.de", Input: "munchen.de", Match: false},
+	}
+	for i, d := range idna {
+		err := Match(d.Input, d.Pattern, WithDialect(Hostname), WithIDNA())
+		if d.Match && err != nil {
+			t.Errorf("%d) match failed: %s (%s): %v", i, d.Input, d.Pattern, err)
+		}
+		if !d.Match && err == nil {
+			t.Errorf("%d) unexpected match: %s (%s)", i, d.Input, d.Pattern)
+		}
+	}
+}
+
+func testDialectGoPackage(t *testing.T) {
+	data := []MatchCase{
+		{Pattern: "net/http", Input: "net/http", Match: true},
+		{Pattern: "net/http", Input: "net/http/httptest", Match: false},
+		{Pattern: "net/...", Input: "net", Match: true},
+		{Pattern: "net/...", Input: "net/http", Match: true},
+		{Pattern: "net/...", Input: "net/http/httptest", Match: true},
+		{Pattern: "net/...", Input: "netip", Match: false},
+		{Pattern: "net...", Input: "netip", Match: true},
+		{Pattern: "net...", Input: "net/http", Match: true},
+		{Pattern: "github.com/org/...", Input: "github.com/org/repo/cmd/tool", Match: true},
+		{Pattern: "github.com/org/...", Input: "github.com/other/repo", Match: false},
+		{Pattern: "github.com/.../cmd", Input: "github.com/org/repo/cmd", Match: true},
+		{Pattern: "github.com/.../cmd", Input: "github.com/org/repo/cmd/tool", Match: false},
+		{Pattern: "x/...y", Input: "x/a/b/cy", Match: true},
+		{Pattern: "x/...y", Input: "x/y", Match: true},
+		{Pattern: "x/a...b...c", Input: "x/a1/2b3/4c", Match: true},
+		{Pattern: "x/a...b...c", Input: "x/abc", Match: true},
+		{Pattern: "x/a...b...c", Input: "x/a/c", Match: false},
+		{Pattern: "./...", Input: ".", Match: true},
+		{Pattern: "./...", Input: "./internal/parser", Match: true},
+		{Pattern: "./...", Input: "internal/parser", Match: false},
+		{Pattern: "all", Input: "golang.org/x/net/idna", Match: true},
+		{Pattern: "std", Input: "encoding/json", Match: true},
+		{Pattern: "std", Input: "golang.org/x/net/idna", Match: false},
+		{Pattern: "std", Input: "cmd/go", Match: false},
+		{Pattern: "cmd", Input: "cmd/go/internal/load", Match: true},
+		{Pattern: "net/*", Input: "net/*", Match: true},
+		{Pattern: "net/*", Input: "net/http", Match: false},
+	}
+	testDialectCases(t, GoPackage, data)
+}
+
+func testDialectWindows(t *testing.T) {
+	data := []MatchCase{
+		{Pattern: `C:\Users\*\AppData\**\*.log`, Input: `C:\Users\midbel\AppData\Local\Temp\setup.log`, Match: true},
+		{Pattern: `C:\Users\*\AppData\**\*.log`, Input: `c:/users/midbel/appdata/install.LOG`, Match: true},
+		{Pattern: `C:\Users\*\AppData\**\*.log`, Input: `D:\Users\midbel\AppData\install.log`, Match: false},
+		{Pattern: `C:\Users\*\AppData\**\*.log`, Input: `C:\Users\midbel\Documents\install.log`, Match: false},
+		{Pattern: `?:\Windows\*.exe`, Input: `E:\Windows\notepad.exe`, Match: true},
+		{Pattern: `C:\Windows\*.exe`, Input: `\\?\C:\Windows\notepad.exe`, Match: true},
+		{Pattern: `C:*.exe`, Input: `C:\notepad.exe`, Match: true},
+		{Pattern: `\\server\share\*.txt`, Input: `\\SERVER\share\notes.txt`, Match: true},
+		{Pattern: `\\server\share\*.txt`, Input: `\\?\UNC\server\share\notes.txt`, Match: true},
+		{Pattern: `\\*\share\*.txt`, Input: `//fileserver/share/notes.txt`, Match: true},
+		{Pattern: `\\server\share\*.txt`, Input: `\server\share\notes.txt`, Match: false},
+		{Pattern: `\\server\share\*.txt`, Input: `server\share\notes.txt`, Match: false},
+		{Pattern: `\Windows\*`, Input: `\windows\system32`, Match: true},
+		{Pattern: `\Windows\*`, Input: `C:\windows\system32`, Match: false},
+		{Pattern: `*\@(bin|obj)\**`, Input: `project\OBJ\Debug\app.pdb`, Match: true},
+		{Pattern: "report`*.txt", Input: `report*.txt`, Match: true},
+		{Pattern: "report`*.txt", Input: `report1.txt`, Match: false},
+		{Pattern: "[[]draft].txt", Input: `[DRAFT].txt`, Match: true},
+	}
+	testDialectCases(t, Windows, data)
+
+	if err := Match(`C:\a\b*`, `C:\a\b^*`, WithDialect(Windows), WithEscape('^')); err != nil {
+		t.Errorf("match failed with alternate escape: %v", err)
+	}
+	if err := Match(`C:\a\bc`, `C:\a\b^*`, WithDialect(Windows), WithEscape('^')); err == nil {
+		t.Errorf("escaped star matches any characters")
+	}
+}
+
+func testDialectFilepath(t *testing.T) {
+	data := []MatchCase{
+		{Pattern: "*", Input: "abc", Match: true},
+		{Pattern: "*", Input: ".hidden", Match: true},
+		{Pattern: "*", Input: "a/b", Match: false},
+		{Pattern: "a/*/c", Input: "a/b/c", Match: true},
+		{Pattern: "**", Input: "ab", Match: true},
+		{Pattern: "**", Input: "a/b", Match: false},
+		{Pattern: "a?c", Input: "abc", Match: true},
+		{Pattern: "a?c", Input: "a/c", Match: false},
+		{Pattern: "[a-c]x", Input: "bx", Match: true},
+		{Pattern: "[^a]bc", Input: "xbc", Match: true},
+		{Pattern: "[^a]bc", Input: "abc", Match: false},
+		{Pattern: "[!a]bc", Input: "!bc", Match: true},
+		{Pattern: "[!a]bc", Input: "xbc", Match: false},
+		{Pattern: `[\]]`, Input: "]", Match: true},
+		{Pattern: `\*`, Input: "*", Match: true},
+		{Pattern: `\*`, Input: "a", Match: false},
+		{Pattern: "/a", Input: "/a", Match: true},
+		{Pattern: "/a", Input: "a", Match: false},
+		{Pattern: "a/", Input: "a", Match: false},
+		{Pattern: "", Input: "", Match: true},
+		{Pattern: "@(a|b)", Input: "a", Match: false},
+		{Pattern: "@(a|b)", Input: "@(a|b)", Match: true},
+		{Pattern: "{a,b}", Input: "{a,b}", Match: true},
+		{Pattern: " a", Input: " a", Match: true},
+	}
+	testDialectCases(t, FilepathMatch, data)
+	for i, d := range data {
+		if ok, _ := filepath.Match(d.Pattern, d.Input); ok != d.Match {
+			t.Errorf("%d) filepath.Match disagrees: %s (%s)", i, d.Input, d.Pattern)
+		}
+	}
+}
+
+func testDialectBash(t *testing.T) {
+	data := []MatchCase{
+		{Pattern: "*", Input: ".hidden", Match: true},
+		{Pattern: "**/*.go", Input: "a/b/c.go", Match: true},
+		{Pattern: "**/*.go", Input: "c.go", Match: true},
+		{Pattern: "a/**", Input: "a/b/c", Match: true},
+		{Pattern: "@(foo|bar).txt", Input: "bar.txt", Match: true},
+		{Pattern: "!(*.go)", Input: "main.go", Match: false},
+		{Pattern: "!(*.go)", Input: "README", Match: true},
+		{Pattern: "+(ab)", Input: "abab", Match: true},
+		{Pattern: "?(ab)c", Input: "c", Match: true},
+		{Pattern: "[!a]x", Input: "bx", Match: true},
+		{Pattern: "[^a]x", Input: "ax", Match: false},
+		{Pattern: "[]a]", Input: "]", Match: true},
+		{Pattern: "{a,b}", Input: "a", Match: false},
+		{Pattern: "{a,b}", Input: "{a,b}", Match: true},
+	}
+	testDialectCases(t, Bash, data)
+}
+
+func testDialectZsh(t *testing.T) {
+	data := []MatchCase{
+		{Pattern: "*", Input: ".hidden", Match: false},
+		{Pattern: "*", Input: "visible", Match: true},
+		{Pattern: ".*", Input: ".hidden", Match: true},
+		{Pattern: "(foo|bar).c", Input: "bar.c", Match: true},
+		{Pattern: "(foo|bar).c", Input: "baz.c", Match: false},
+		{Pattern: "(.foo|bar)", Input: ".foo", Match: true},
+		{Pattern: "(.foo|bar)", Input: "bar", Match: true},
+		{Pattern: "(.foo|*)", Input: ".bar", Match: false},
+		{Pattern: "(.foo|*).c", Input: ".foo.c", Match: true},
+		{Pattern: "(.foo|*).c", Input: ".bar.c", Match: false},
+		{Pattern: "(|x)*", Input: ".hidden", Match: false},
+		{Pattern: "(.|x)*", Input: ".hidden", Match: true},
+		{Pattern: "^*.o", Input: "main.c", Match: true},
+		{Pattern: "^*.o", Input: "main.o", Match: false},
+		{Pattern: "a#b", Input: "aaab", Match: true},
+		{Pattern: "a#b", Input: "b", Match: true},
+		{Pattern: "a##b", Input: "b", Match: false},
+		{Pattern: "a##b", Input: "ab", Match: true},
+		{Pattern: "(ab)##", Input: "abab", Match: true},
+		{Pattern: "[0-9]##.log", Input: "2024.log", Match: true},
+		{Pattern: "**/*.c", Input: "a/b/c.c", Match: true},
+		{Pattern: "**/*.c", Input: "c.c", Match: true},
+		{Pattern: "a/**", Input: "a/b", Match: true},
+		{Pattern: "a/**", Input: "a/b/c", Match: false},
+		{Pattern: "[^a]x", Input: "bx", Match: true},
+		{Pattern: "@(a|b)", Input: "@a", Match: true},
+	}
+	testDialectCases(t, Zsh, data)
+}
+
+func testDialectDoublestar(t *testing.T) {
+	data := []MatchCase{
+		{Pattern: "*", Input: ".hidden", Match: true},
+		{Pattern: "**/*.go", Input: "a/b/c.go", Match: true},
+		{Pattern: "**/*.go", Input: "c.go", Match: true},
+		{Pattern: "a/**", Input: "a", Match: true},
+		{Pattern: "a/**", Input: "a/b/c", Match: true},
+		{Pattern: "a**", Input: "abc", Match: true},
+		{Pattern: "a**", Input: "a/b", Match: false},
+		{Pattern: "{a,b}/*.go", Input: "b/x.go", Match: true},
+		{Pattern: "{a,b/c}/*.go", Input: "b/c/x.go", Match: true},
+		{Pattern: "{a,b/c}/*.go", Input: "b/x.go", Match: false},
+		{Pattern: "*.{go,mod}", Input: "go.mod", Match: true},
+		{Pattern: "[!a]x", Input: "bx", Match: true},
+		{Pattern: "@(a|b)", Input: "a", Match: false},
+	}
+	testDialectCases(t, Doublestar, data)
+}
+
+func testDialectCases(t *testing.T, dialect Dialect, data []MatchCase) {
+	t.Helper()
+	for i, d := range data {
+		err := Match(d.Input, d.Pattern, WithDialect(dialect))
+		if d.Match && err != nil {
+			t.Errorf("%d) match failed: %s (%s): %v", i, d.Input, d.Pattern, err)
+		}
+		if !d.Match && err == nil {
+			t.Errorf("%d) unexpected match: %s (%s)", i, d.Input, d.Pattern)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package glob
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+var (
+	// ErrInfinite is returned by Enumerate when a matcher matches an infinite
+	// number of paths.
+	ErrInfinite = errors.New("infinite number of paths")
+	// ErrTooMany is returned by Enumerate when the number of paths matched by
+	// a matcher exceeds the limit.
+	ErrTooMany = errors.New("too many paths")
+)
+
+const defaultLimit = 10000
+
+// Enumerate returns the sorted list of the paths matched by m, its segments
+// being joined by the separator of the dialect given in opts or, when m is a
+// Pattern, by the one of its dialect: a slash, a dot for AMQP and Hostname
+// and a backslash for Windows. Only the bounded parts of a pattern, like
+// alternatives and bracket expressions, are enumerated: an error wrapping
+// ErrInfinite is returned when m contains a star, a globstar or a negation
+// and an error wrapping ErrTooMany when there are more than limit paths,
+// 10000 if limit is not positive. Since ? matches any character but a
+// separator, it always gives ErrTooMany. A nil Matcher gives no path.
+func Enumerate(m Matcher, limit int, opts ...Option) ([]string, error) {
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if isNil(m) {
+		return nil, nil
+	}
+	cfg := configureFor(opts, m)
+	e := enumerator{
+		limit: limit,
+		cfg:   cfg,
+		seps:  cfg.separators(),
+	}
+	list, err := e.paths(m)
+	if err != nil {
+		return nil, fmt.Errorf("enumerate: %s: %w", m, err)
+	}
+	return list, nil
+}
+
+type enumerator struct {
+	limit int
+	cfg   *config
+	// seps are the separators of the dialect, the first one joining the
+	// segments
+	seps string
+}
+
+func (e *enumerator) separator() string {
+	k, _ := utf8.DecodeRuneInString(e.seps)
+	return string(k)
+}
+
+// paths gives the sorted strings matched by m.
+func (e *enumerator) paths(m Matcher) ([]string, error) {
+	switch m := m.(type) {
+	case *Pattern:
+		return e.paths(m.Matcher)
+	case *automaton:
+		return e.paths(m.src)
+	case *simple:
+		if isGlobstar(m) {
+			return nil, ErrInfinite
+		}
+		return e.pattern(m.pattern)
+	case *element:
+		var (
+			list = []string{""}
+			sep  string
+		)
+		for _, m := range appendChain(nil, m) {
+			xs, err := e.paths(m)
+			if err != nil {
+				return nil, err
+			}
+			if list, err = e.product(list, xs, sep); err != nil {
+				return nil, err
+			}
+			sep = e.separator()
+			if e.cfg.dialect == Windows && len(xs) == 1 && (xs[0] == uncPrefix || xs[0] == rootPrefix) {
+				// the root and UNC prefixes end with a separator
+				sep = ""
+			}
+		}
+		return list, nil
+	case *multiple:
+		list := []string{""}
+		for _, m := range m.ms {
+			xs, err := e.paths(m)
+			if err != nil {
+				return nil, err
+			}
+			if list, err = e.product(list, xs, ""); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case *group:
+		var list []string
+		for _, m := range m.ms {
+			xs, err := e.paths(m)
+			if err != nil {
+				return nil, err
+			}
+			if list, err = e.union(list, xs); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case *any:
+		return e.repeat(m)
+	case *visible:
+		list, err := e.paths(m.inner)
+		if err != nil {
+			return nil, err
+		}
+		return e.filter(m, list), nil
+	case *intersect:
+		// the paths of the first finite matcher are filtered by the others
+		err := ErrInfinite
+		for _, x := range m.ms {
+			var list []string
+			if list, err = e.paths(x); err == nil {
+				return e.filter(m, list), nil
+			}
+		}
+		return nil, err
+	case *not, *complement:
+		return nil, ErrInfinite
+	default:
+		return nil, fmt.Errorf("%T can not be enumerated", m)
+	}
+}
+
+// repeat gives the strings made of min to max strings of the inner matcher
+// of a.
+func (e *enumerator) repeat(a *any) ([]string, error) {
+	inner, err := e.paths(a.inner)
+	if err != nil {
+		return nil, err
+	}
+	if a.max == 0 {
+		if len(inner) > 1 || (len(inner) == 1 && inner[0] != "") {
+			return nil, ErrInfinite
+		}
+		if a.min > 0 && len(inner) == 0 {
+			return nil, nil
+		}
+		return []string{""}, nil
+	}
+	var (
+		list []string
+		part = []string{""}
+	)
+	for i := 1; i <= a.max; i++ {
+		if i > a.min {
+			if list, err = e.union(list, part); err != nil {
+				return nil, err
+			}
+		}
+		if part, err = e.product(part, inner, ""); err != nil {
+			return nil, err
+		}
+	}
+	return e.union(list, part)
+}
+
+// pattern gives the strings matched by the pattern of a simple matcher.
+func (e *enumerator) pattern(pat string) ([]string, error) {
+	var (
+		list = []string{""}
+		seps []runeRange
+	)
+	for _, k := range e.seps {
+		seps = append(seps, runeRange{lo: k, hi: k})
+	}
+	seps = normalizeRanges(seps)
+	for i := 0; i < len(pat); {
+		k, n := utf8.DecodeRuneInString(pat[i:])
+		var rs []runeRange
+		switch {
+		case k == star:
+			return nil, ErrInfinite
+		case k == mark:
+			rs = negateRanges(seps)
+		case k == lsquare && classSize(pat[i:]) > 0:
+			n = classSize(pat[i:])
+			rs = intersectRanges(classRanges(pat[i+1:i+n-1]), negateRanges(seps))
+		case k == backslash && i+n < len(pat):
+			k, z := utf8.DecodeRuneInString(pat[i+n:])
+			n += z
+			rs = []runeRange{{lo: k, hi: k}}
+		default:
+			rs = []runeRange{{lo: k, hi: k}}
+		}
+		i += n
+
+		xs, err := e.runes(rs)
+		if err != nil {
+			return nil, err
+		}
+		if list, err = e.product(list, xs, ""); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// runes gives the valid characters of rs.
+func (e *enumerator) runes(rs []runeRange) ([]string, error) {
+	var size int
+	for _, r := range rs {
+		size += int(r.hi-r.lo) + 1
+	}
+	if size > e.limit {
+		return nil, ErrTooMany
+	}
+	list := make([]string, 0, size)
+	for _, r := range rs {
+		for k := r.lo; k <= r.hi; k++ {
+			if utf8.ValidRune(k) {
+				list = append(list, string(k))
+			}
+		}
+	}
+	sort.Strings(list)
+	return list, nil
+}
+
+// product gives the concatenations of the strings of a and b, separated by
+// sep.
+func (e *enumerator) product(a, b []string, sep string) ([]string, error) {
+	if len(a) == 0 || len(b) == 0 {
+		return nil, nil
+	}
+	if len(a) > e.limit/len(b) {
+		return nil, ErrTooMany
+	}
+	list := make([]string, 0, len(a)*len(b))
+	for _, x := range a {
+		for _, y := range b {
+			list = append(list, x+sep+y)
+		}
+	}
+	return e.sort(list)
+}
+
+func (e *enumerator) union(a, b []string) ([]string, error) {
+	list := make([]string, 0, len(a)+len(b))
+	list = append(list, a...)
+	list = append(list, b...)
+	return e.sort(list)
+}
+
+// sort sorts list and removes its duplicates.
+func (e *enumerator) sort(list []string) ([]string, error) {
+	sort.Strings(list)
+	var j int
+	for i := range list {
+		if i > 0 && list[i] == list[j-1] {
+			continue
+		}
+		list[j] = list[i]
+		j++
+	}
+	list = list[:j]
+	if len(list) > e.limit {
+		return nil, ErrTooMany
+	}
+	return list, nil
+}
+
+// filter keeps the strings of list matched by m.
+func (e *enumerator) filter(m Matcher, list []string) []string {
+	var j int
+	for _, str := range list {
+		if matchSegments(m, e.cfg.dialect.split(str, e.cfg)) == nil {
+			list[j] = str
+			j++
+		}
+	}
+	return list[:j]
+}
@@ -0,0 +1,119 @@
+package glob
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestEnumerate(t *testing.T) {
+	data := []struct {
+		Pattern string
+		Paths   []string
+		Opts    []Option
+	}{
+		{Pattern: "src/main.go", Paths: []string{"src/main.go"}},
+		{
+			Pattern: "@(dev|prod)/config.@(yaml|json)",
+			Paths:   []string{"dev/config.json", "dev/config.yaml", "prod/config.json", "prod/config.yaml"},
+		},
+		{Pattern: "file[0-2].[ch]", Paths: []string{"file0.c", "file0.h", "file1.c", "file1.h", "file2.c", "file2.h"}},
+		{Pattern: "a?(b)c", Paths: []string{"ac", "abc"}},
+		{Pattern: "@(a/b|c)/d", Paths: []string{"a/b/d", "c/d"}},
+		{Pattern: "@(a|a)/[a/]", Paths: []string{"a/a"}},
+		{Pattern: `\*.md`, Paths: []string{"*.md"}},
+		{Pattern: "{x,y}{1,2}", Paths: []string{"x1", "x2", "y1", "y2"}, Opts: []Option{WithDialect(Doublestar)}},
+		{Pattern: "[.a]b", Paths: []string{"ab"}, Opts: []Option{WithDialect(Zsh)}},
+		{Pattern: "[a-c]", Paths: []string{"a", "b", "c"}, Opts: []Option{WithDialect(FilepathMatch)}},
+		{Pattern: "stock.usd.nyse", Paths: []string{"stock.usd.nyse"}, Opts: []Option{WithDialect(AMQP)}},
+		{Pattern: "WWW.Example.com", Paths: []string{"www.example.com"}, Opts: []Option{WithDialect(Hostname)}},
+		{Pattern: `C:\src\[ab].go`, Paths: []string{`c:\src\a.go`, `c:\src\b.go`}, Opts: []Option{WithDialect(Windows)}},
+		{Pattern: `\\server\share\@(a|b)`, Paths: []string{`\\server\share\a`, `\\server\share\b`}, Opts: []Option{WithDialect(Windows)}},
+		{Pattern: `\Windows\x[/.]`, Paths: []string{`\windows\x.`}, Opts: []Option{WithDialect(Windows)}},
+	}
+	for _, d := range data {
+		m, err := Compile(d.Pattern, d.Opts...)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", d.Pattern, err)
+		}
+		got, err := Enumerate(m, 0, d.Opts...)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", d.Pattern, err)
+			continue
+		}
+		if p, err := CompilePattern(d.Pattern, d.Opts...); err != nil {
+			t.Errorf("%q: unexpected error: %s", d.Pattern, err)
+		} else if xs, _ := Enumerate(p, 0); !reflect.DeepEqual(xs, got) {
+			t.Errorf("%q: dialect of Pattern not used: want %q, got %q", d.Pattern, got, xs)
+		}
+		want := append([]string(nil), d.Paths...)
+		sort.Strings(want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: paths mismatched! want %q, got %q", d.Pattern, want, got)
+			continue
+		}
+		for _, p := range got {
+			if err := MatchWith(p, m, d.Opts...); err != nil {
+				t.Errorf("%q: %q not matched", d.Pattern, p)
+			}
+		}
+	}
+}
+
+func TestEnumerateComposed(t *testing.T) {
+	data := []struct {
+		Matcher Matcher
+		Paths   []string
+	}{
+		{Matcher: Or(mustCompile(t, "a/b"), mustCompile(t, "c")), Paths: []string{"a/b", "c"}},
+		{Matcher: And(mustCompile(t, "src/*.go"), mustCompile(t, "@(src|lib)/@(a|b).go")), Paths: []string{"src/a.go", "src/b.go"}},
+		{Matcher: And(mustCompile(t, "[a-e]"), Not(mustCompile(t, "[b-d]"))), Paths: []string{"a", "e"}},
+		{Matcher: Repeat(mustCompile(t, "@(a|b)"), 1, 2), Paths: []string{"a", "aa", "ab", "b", "ba", "bb"}},
+	}
+	for i, d := range data {
+		got, err := Enumerate(d.Matcher, 0)
+		if err != nil {
+			t.Errorf("%d) unexpected error: %s", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, d.Paths) {
+			t.Errorf("%d) paths mismatched! want %q, got %q", i, d.Paths, got)
+		}
+	}
+}
+
+func TestEnumerateError(t *testing.T) {
+	data := []struct {
+		Pattern string
+		Limit   int
+		Err     error
+	}{
+		{Pattern: "*.go", Err: ErrInfinite},
+		{Pattern: "src/**", Err: ErrInfinite},
+		{Pattern: "!(a)", Err: ErrInfinite},
+		{Pattern: "+(a|b)", Err: ErrInfinite},
+		// ? is bounded but matches any character except a separator
+		{Pattern: "?", Err: ErrTooMany},
+		{Pattern: "a?", Limit: 1 << 20, Err: ErrTooMany},
+		{Pattern: "[!a]", Err: ErrTooMany},
+		{Pattern: "[0-9][0-9][0-9][0-9][0-9]", Err: ErrTooMany},
+		{Pattern: "@(a|b|c)/@(a|b|c)", Limit: 8, Err: ErrTooMany},
+	}
+	for _, d := range data {
+		m, err := Compile(d.Pattern)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", d.Pattern, err)
+		}
+		got, err := Enumerate(m, d.Limit)
+		if !errors.Is(err, d.Err) {
+			t.Errorf("%q: error mismatched! want %s, got %v (%q)", d.Pattern, d.Err, err, got)
+		}
+	}
+	if list, err := Enumerate(nil, 0); err != nil || len(list) != 0 {
+		t.Errorf("nil matcher: expected no path, got %q (%v)", list, err)
+	}
+	if _, err := Enumerate(Seq(version{}), 0); err == nil {
+		t.Errorf("custom matcher: expected error")
+	}
+}
@@ -0,0 +1,19 @@
+package glob
+
+import (
+	"fmt"
+
+	"github.com/midbel/glob/ast"
+)
+
+// PatternError reports a malformed pattern with the position of the
+// offending character.
+type PatternError = ast.PatternError
+
+func patternError(pattern string, offset int, msg string, args ...interface{}) error {
+	return &PatternError{
+		Pattern: pattern,
+		Offset:  offset,
+		Msg:     fmt.Sprintf(msg, args...),
+	}
+}
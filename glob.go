@@ -18,21 +18,27 @@ type Glob struct {
 }
 
 func New(pattern string, dirs ...string) (*Glob, error) {
-	if len(dirs) == 0 {
-		if strings.HasPrefix(pattern, "/") {
-			dirs = append(dirs, "/")
-		} else {
-			cwd, err := os.Getwd()
-			if err != nil {
-				return nil, err
-			}
-			dirs = append(dirs, cwd)
-		}
-	}
 	m, err := Compile(pattern)
 	if err != nil {
 		return nil, err
 	}
+	if len(dirs) == 0 && strings.HasPrefix(pattern, "/") {
+		dirs = append(dirs, "/")
+	}
+	return NewMatcher(m, dirs...)
+}
+
+// NewMatcher is like New but walks dirs with a Matcher, like the ones
+// combined with Seq, Or, And and Not. The current directory is walked when no
+// directory is given.
+func NewMatcher(m Matcher, dirs ...string) (*Glob, error) {
+	if len(dirs) == 0 {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, cwd)
+	}
 	queue := make(chan entry)
 	go func() {
 		defer close(queue)
@@ -70,7 +76,7 @@ func glob(dir string, m Matcher, q chan<- entry) {
 		if e.Dir {
 			glob(file, next, q)
 		}
-		if next == nil {
+		if next == nil || errors.Is(err, ErrMatch) {
 			q <- entry{
 				Name: file,
 				Dir:  e.Dir,
@@ -1,6 +1,8 @@
 package glob
 
 import (
+	"io/fs"
+	"path/filepath"
 	"sort"
 	"strings"
 	"testing"
@@ -21,8 +23,13 @@ var files = []entry{
 	{Name: "bin/testglob-win64.exe"},
 }
 
+var modules = map[string]string{
+	"src/github.com/midbel/glob/go.mod": "module github.com/midbel/glob\n\ngo 1.21\n",
+}
+
 func init() {
 	scan = scanmap
+	readFile = readmap
 }
 
 type GlobCase struct {
@@ -59,12 +66,78 @@ func TestGlob(t *testing.T) {
 		},
 	}
 	for i, d := range data {
-		testGlobCase(t, d, i)
+		g, err := New(d.Pattern, d.Base)
+		testGlobCase(t, g, err, d, i)
+	}
+}
+
+func TestPackages(t *testing.T) {
+	data := []GlobCase{
+		{
+			Pattern: "src/...",
+			Files:   []string{"src/github.com/midbel/glob"},
+		},
+		{
+			Pattern: "src/github.com/midbel/...",
+			Files:   []string{"src/github.com/midbel/glob"},
+		},
+		{
+			Pattern: "src/github.com/midbel/toml/...",
+		},
+		{
+			Pattern: "bin/...",
+		},
+		{
+			Pattern: "github.com/midbel/...",
+			Base:    "src/github.com/midbel/glob",
+			Files:   []string{"src/github.com/midbel/glob"},
+		},
+		{
+			Pattern: "github.com/midbel/toml/...",
+			Base:    "src/github.com/midbel/glob",
+		},
+	}
+	for i, d := range data {
+		g, err := Packages(d.Pattern, d.Base)
+		testGlobCase(t, g, err, d, i)
+	}
+}
+
+func TestNewMatcher(t *testing.T) {
+	data := []struct {
+		GlobCase
+		Matcher Matcher
+	}{
+		{
+			GlobCase: GlobCase{
+				Pattern: "src/**/*.go and not **/*_test.go",
+				Files: []string{
+					"src/github.com/midbel/glob/glob.go",
+					"src/github.com/midbel/glob/parse.go",
+					"src/github.com/midbel/glob/match.go",
+				},
+			},
+			Matcher: And(mustCompile(t, "src/**/*.go"), Not(mustCompile(t, "**/*_test.go"))),
+		},
+		{
+			GlobCase: GlobCase{
+				Pattern: "**/README.md or bin/*exe",
+				Files: []string{
+					"src/github.com/midbel/glob/README.md",
+					"src/github.com/midbel/toml/README.md",
+					"bin/testglob-win64.exe",
+				},
+			},
+			Matcher: Or(mustCompile(t, "**/README.md"), mustCompile(t, "bin/*exe")),
+		},
+	}
+	for i, d := range data {
+		g, err := NewMatcher(d.Matcher, d.Base)
+		testGlobCase(t, g, err, d.GlobCase, i)
 	}
 }
 
-func testGlobCase(t *testing.T, d GlobCase, i int) {
-	g, err := New(d.Pattern, d.Base)
+func testGlobCase(t *testing.T, g *Glob, err error, d GlobCase, i int) {
 	if err != nil {
 		t.Errorf("%d) invalid pattern %s: %v", i, d.Pattern, err)
 		return
@@ -95,6 +168,14 @@ func testGlobCase(t *testing.T, d GlobCase, i int) {
 	}
 }
 
+func readmap(file string) ([]byte, error) {
+	str, ok := modules[filepath.ToSlash(file)]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	return []byte(str), nil
+}
+
 func scanmap(dir string) (<-chan entry, error) {
 	queue := make(chan entry)
 	go func() {
@@ -0,0 +1,212 @@
+package glob
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	dots      = "..."
+	maxDots   = 8
+	goModFile = "go.mod"
+)
+
+// readFile reads the go.mod files. It is replaced in tests like scan.
+var readFile = os.ReadFile
+
+// compileGoPackage compiles a package pattern as described by go help
+// packages:
+//
+//   - ... matches any string, including the empty string and slashes
+//   - a pattern ending with /... also matches its parent (net/... matches net)
+//   - all matches every package, std the packages of the standard library
+//     (import path without dot in its first element) and cmd the commands
+//     of the Go repository
+func compileGoPackage(pattern string, _ *config) (Matcher, error) {
+	switch pattern {
+	case "":
+		return nil, patternError(pattern, 0, "gopackage: empty pattern")
+	case "all":
+		pattern = dots
+	case "cmd":
+		pattern = "cmd/..."
+	case "std":
+		std := []Matcher{
+			&not{inner: &group{ms: []Matcher{
+				linkMatchers([]Matcher{&simple{pattern: "*.*"}}),
+				linkMatchers([]Matcher{&simple{pattern: "cmd"}}),
+			}}},
+			&simple{pattern: "**"},
+		}
+		return linkMatchers(std), nil
+	}
+	var (
+		parts = strings.Split(pattern, "/")
+		ms    = make([]Matcher, 0, len(parts))
+		size  int
+	)
+	for i, p := range parts {
+		if i > 0 && i == len(parts)-1 && p == dots {
+			ms = append(ms, &simple{pattern: "**"})
+			break
+		}
+		m, err := expandDots(p)
+		if err != nil {
+			return nil, patternError(pattern, size, "gopackage: %s", err)
+		}
+		size += len(p) + 1
+		ms = append(ms, m)
+	}
+	return linkMatchers(ms), nil
+}
+
+// expandDots gives the matcher of one segment of a package pattern. Each ...
+// either stays in the segment like a star or spans multiple segments. The
+// alternatives are then grouped together.
+func expandDots(seg string) (Matcher, error) {
+	parts := strings.Split(seg, dots)
+	if len(parts) == 1 {
+		return &simple{pattern: quote(seg)}, nil
+	}
+	if len(parts) > maxDots+1 {
+		return nil, fmt.Errorf("too many %s in %q", dots, seg)
+	}
+	type chain struct {
+		ms  []Matcher
+		cur string
+	}
+	cs := []chain{{cur: quote(parts[0])}}
+	for _, p := range parts[1:] {
+		p = quote(p)
+		xs := make([]chain, 0, len(cs)*2)
+		for _, c := range cs {
+			xs = append(xs, chain{ms: c.ms, cur: c.cur + string(star) + p})
+
+			ms := make([]Matcher, len(c.ms), len(c.ms)+2)
+			copy(ms, c.ms)
+			ms = append(ms, &simple{pattern: c.cur + string(star)}, &simple{pattern: "**"})
+			xs = append(xs, chain{ms: ms, cur: string(star) + p})
+		}
+		cs = xs
+	}
+	var grp group
+	for _, c := range cs {
+		ms := make([]Matcher, 0, len(c.ms)+1)
+		ms = append(ms, c.ms...)
+		ms = append(ms, &simple{pattern: c.cur})
+		grp.ms = append(grp.ms, linkMatchers(ms))
+	}
+	return &grp, nil
+}
+
+// Packages returns a Glob that gives the directories containing Go source
+// files below dirs that match pattern, a package pattern as accepted by go
+// list (see GoPackage).
+//
+// Directories are matched by their import path: relative patterns (eg:
+// ./...) are matched against the path of the directories relative to the
+// base directory prefixed by "./". Other patterns use the module path found
+// in the go.mod of the base directory if any. Like the go command, the
+// directories named testdata or starting with a dot or an underscore are
+// ignored.
+func Packages(pattern string, dirs ...string) (*Glob, error) {
+	if len(dirs) == 0 {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, cwd)
+	}
+	m, err := Compile(pattern, WithDialect(GoPackage))
+	if err != nil {
+		return nil, err
+	}
+	queue := make(chan entry)
+	go func() {
+		defer close(queue)
+		for _, d := range dirs {
+			root := "."
+			if pattern != "." && !strings.HasPrefix(pattern, "./") {
+				root = modulePath(d)
+			}
+			if m, ok := walkRoot(m, root); m != nil || ok {
+				packages(d, m, ok, queue)
+			}
+		}
+	}()
+	return &Glob{queue: queue, keepDir: true}, nil
+}
+
+func walkRoot(m Matcher, root string) (Matcher, bool) {
+	if root == "" {
+		return m, accept(m)
+	}
+	var err error
+	for _, p := range strings.Split(root, "/") {
+		if m == nil {
+			return nil, false
+		}
+		m, err = m.Match(p)
+		if err != nil && !errors.Is(err, ErrMatch) {
+			return nil, false
+		}
+	}
+	return m, m == nil || errors.Is(err, ErrMatch)
+}
+
+func packages(dir string, m Matcher, match bool, q chan<- entry) {
+	es, err := scan(dir)
+	if err != nil {
+		return
+	}
+	var (
+		dirs   []string
+		source bool
+	)
+	for e := range es {
+		if !e.Dir {
+			source = source || filepath.Ext(e.Name) == ".go"
+			continue
+		}
+		if strings.HasPrefix(e.Name, "_") || e.Name == "testdata" {
+			continue
+		}
+		dirs = append(dirs, e.Name)
+	}
+	if match && source {
+		q <- entry{
+			Name: dir,
+			Dir:  true,
+		}
+	}
+	if m == nil {
+		return
+	}
+	for _, d := range dirs {
+		next, err := m.Match(d)
+		if err != nil && !errors.Is(err, ErrMatch) {
+			continue
+		}
+		packages(filepath.Join(dir, d), next, next == nil || errors.Is(err, ErrMatch), q)
+	}
+}
+
+// modulePath gives the path declared by the module directive of the go.mod
+// file found in dir, the directory being walked. It returns an empty string
+// if the file does not exist.
+func modulePath(dir string) string {
+	buf, err := readFile(filepath.Join(dir, goModFile))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(buf), "\n") {
+		fs := strings.Fields(line)
+		if len(fs) >= 2 && fs[0] == "module" {
+			return strings.Trim(fs[1], "\"`")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+package glob
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	hostMaxLength  = 253
+	labelMaxLength = 63
+
+	acePrefix = "xn--"
+)
+
+// compileHostname compiles a DNS name following the restrictions of the
+// section 6.4.3 of RFC 6125:
+//
+//   - labels are separated by a dot and compared without regard to case
+//   - the wildcard can only be the complete left-most label and it matches
+//     exactly one non empty label. It never matches the parent domain
+//   - a wildcard is not allowed directly above a top-level domain
+//
+// Partial wildcards (eg: w*.example.com) are rejected since they are
+// forbidden in certificates and ambiguous with internationalized labels.
+func compileHostname(pattern string, cfg *config) (Matcher, error) {
+	name := normalizeHost(pattern, cfg.idna)
+	if len(name) == 0 {
+		return nil, patternError(pattern, 0, "hostname: empty pattern")
+	}
+	if len(name) > hostMaxLength {
+		return nil, patternError(pattern, 0, "hostname: name too long (%d bytes)", len(name))
+	}
+	var (
+		labels = strings.Split(name, ".")
+		ms     = make([]Matcher, 0, len(labels))
+		// labels of pattern are kept to report errors at their original offset
+		origin = strings.Split(pattern, ".")
+		offset int
+	)
+	for i, lbl := range labels {
+		if i > 0 {
+			offset += len(origin[i-1]) + 1
+		}
+		if lbl == string(star) {
+			if i > 0 {
+				return nil, patternError(pattern, offset, "hostname: wildcard only allowed in the left-most label")
+			}
+			if len(labels) < 3 {
+				return nil, patternError(pattern, offset, "hostname: wildcard too close to the top-level domain")
+			}
+			ms = append(ms, &simple{pattern: "?*"})
+			continue
+		}
+		if err := checkLabel(pattern, offset, lbl); err != nil {
+			return nil, err
+		}
+		ms = append(ms, &simple{pattern: lbl})
+	}
+	return linkMatchers(ms), nil
+}
+
+// checkLabel verifies the label of pattern found at offset.
+func checkLabel(pattern string, offset int, lbl string) error {
+	if len(lbl) == 0 {
+		return patternError(pattern, offset, "hostname: empty label")
+	}
+	if len(lbl) > labelMaxLength {
+		return patternError(pattern, offset, "hostname: label %q too long (%d bytes)", lbl, len(lbl))
+	}
+	for i, k := range lbl {
+		switch {
+		case k == star:
+			return patternError(pattern, offset+i, "hostname: partial wildcard not supported (%q)", lbl)
+		case k >= utf8.RuneSelf:
+			return patternError(pattern, offset+i, "hostname: non ASCII label %q (IDNA not enabled)", lbl)
+		case k >= 'a' && k <= 'z':
+		case k >= '0' && k <= '9':
+		case k == dash || k == '_':
+		default:
+			return patternError(pattern, offset+i, "hostname: invalid character %q in label %q", k, lbl)
+		}
+	}
+	return nil
+}
+
+// normalizeHost lowercases str and removes the trailing dot of fully
+// qualified names. If idna is set, labels containing non ASCII characters
+// are converted to their ASCII compatible encoding.
+func normalizeHost(str string, idna bool) string {
+	str = strings.ToLower(strings.TrimSuffix(str, "."))
+	if !idna {
+		return str
+	}
+	labels := strings.Split(str, ".")
+	for i, lbl := range labels {
+		if isASCII(lbl) {
+			continue
+		}
+		labels[i] = acePrefix + punycode(lbl)
+	}
+	return strings.Join(labels, ".")
+}
+
+func isASCII(str string) bool {
+	for i := 0; i < len(str); i++ {
+		if str[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
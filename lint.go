@@ -0,0 +1,327 @@
+package glob
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/midbel/glob/ast"
+)
+
+// Diagnostic reports a likely mistake in a pattern. The bytes of Pattern
+// between Offset and End should be replaced by Fix.
+type Diagnostic struct {
+	Pattern string
+	Offset  int
+	End     int
+	Msg     string
+	Fix     string
+	// Error is set when the pattern can not be compiled. The diagnostic has
+	// then no fix.
+	Error bool
+}
+
+func (d Diagnostic) String() string {
+	if d.Error {
+		return fmt.Sprintf("%s (offset %d in %q)", d.Msg, d.Offset, d.Pattern)
+	}
+	return fmt.Sprintf("%s (offset %d in %q, use %q)", d.Msg, d.Offset, d.Pattern, d.Fixed())
+}
+
+// Fixed gives the pattern with the fix applied.
+func (d Diagnostic) Fixed() string {
+	if d.Error {
+		return d.Pattern
+	}
+	return d.Pattern[:d.Offset] + d.Fix + d.Pattern[d.End:]
+}
+
+// Caret returns the line of the pattern holding the first character of the
+// diagnostic with, on the following line, a caret under it.
+func (d Diagnostic) Caret() string {
+	e := PatternError{
+		Pattern: d.Pattern,
+		Offset:  d.Offset,
+	}
+	return e.Caret()
+}
+
+// Lint checks pattern for constructs that are valid but unlikely to do what
+// their author expects:
+//
+//   - ** inside a segment (foo**), where it is the same as *
+//   - runs of stars that can be reduced to one star
+//   - (a|b) without a prefix, which is literal text in the Extended dialect
+//   - a dash in the middle of a bracket expression that is not a range of
+//     letters or digits
+//   - extended patterns of a lone star, like *(*)
+//   - a leading separator, which is ignored when the pattern is walked
+//     relative to the directories given to New (see WithRelative)
+//   - the gotchas of some dialects: a trailing ** in Zsh, ** in
+//     FilepathMatch and wildcards inside words in AMQP
+//
+// A pattern that can not be compiled gives one diagnostic for its error.
+func Lint(pattern string, opts ...Option) []Diagnostic {
+	cfg := configure(opts)
+	if _, err := cfg.dialect.build(pattern, cfg); err != nil {
+		d := Diagnostic{
+			Pattern: pattern,
+			Msg:     err.Error(),
+			Error:   true,
+		}
+		var e *PatternError
+		if errors.As(err, &e) {
+			d.Offset, d.Msg = e.Offset, e.Msg
+		}
+		return []Diagnostic{d}
+	}
+	l := linter{
+		pattern:  pattern,
+		dialect:  cfg.dialect,
+		relative: cfg.relative,
+	}
+	switch cfg.dialect {
+	case AMQP:
+		l.lintWords()
+		return l.list
+	case Extended:
+		l.syntax = cfg.syntax(extendedSyntax).Syntax
+	case Bash:
+		l.syntax = cfg.syntax(bashSyntax).Syntax
+	case Zsh:
+		l.syntax = cfg.syntax(zshSyntax).Syntax
+	case Doublestar:
+		l.syntax = cfg.syntax(doublestarSyntax).Syntax
+	case Windows:
+		l.syntax = cfg.syntax(windowsSyntax).Syntax
+	case FilepathMatch:
+		l.syntax = cfg.syntax(filepathSyntax).Syntax
+	default:
+		return nil
+	}
+	if l.syntax.Separators == "" {
+		l.syntax.Separators = string(slash)
+	}
+	l.lint()
+	return l.list
+}
+
+type linter struct {
+	pattern  string
+	dialect  Dialect
+	syntax   ast.Syntax
+	relative bool
+	list     []Diagnostic
+}
+
+func (l *linter) report(from, to int, fix, msg string, args ...interface{}) {
+	l.list = append(l.list, Diagnostic{
+		Pattern: l.pattern,
+		Offset:  from,
+		End:     to,
+		Msg:     fmt.Sprintf("%s: %s", l.dialect, fmt.Sprintf(msg, args...)),
+		Fix:     fix,
+	})
+}
+
+func (l *linter) lint() {
+	var (
+		pat = l.pattern
+		syn = l.syntax
+	)
+	if l.relative && l.dialect != Windows && l.dialect != FilepathMatch && strings.HasPrefix(pat, string(slash)) {
+		// the separator is trimmed: removing it keeps the same paths
+		l.report(0, 1, "", "leading / is ignored when the pattern is walked relative to directories")
+	}
+	for i := 0; i < len(pat); {
+		k, n := utf8.DecodeRuneInString(pat[i:])
+		switch {
+		case k == syn.Escape:
+			_, z := utf8.DecodeRuneInString(pat[i+n:])
+			n += z
+		case k == lsquare:
+			n = l.lintClass(i)
+		case syn.ExtGlob && strings.ContainsRune("@!+*?", k) && strings.HasPrefix(pat[i+n:], "("):
+			n = l.lintExtGlob(i, i+n)
+		case k == lparen && syn.ExtGlob && !syn.Groups:
+			if j := l.closing(i); j > 0 && l.alternatives(i+1, j) {
+				l.report(i, i, string(arobase), "(...|...) is literal text, prefix it with @ for alternatives")
+			}
+		case k == star:
+			n = l.lintStars(i)
+		}
+		i += n
+	}
+}
+
+// lintStars checks the run of stars starting at i and returns its length.
+func (l *linter) lintStars(i int) int {
+	var (
+		pat = l.pattern
+		j   = i
+	)
+	for j < len(pat) && pat[j] == star && !(l.syntax.ExtGlob && strings.HasPrefix(pat[j+1:], "(")) {
+		j++
+	}
+	n := j - i
+	if n < 2 {
+		return 1
+	}
+	var (
+		before, _ = utf8.DecodeLastRuneInString(pat[:i])
+		after, _  = utf8.DecodeRuneInString(pat[j:])
+		whole     = (i == 0 || strings.ContainsRune(l.syntax.Separators, before)) &&
+			(j == len(pat) || strings.ContainsRune(l.syntax.Separators, after))
+	)
+	switch {
+	case !l.syntax.Globstar:
+		l.report(i, j, string(star), "** is the same as *")
+	case whole && n > 2:
+		l.report(i, j, "**", "redundant stars")
+	case whole && l.dialect == Zsh && j == len(pat):
+		l.report(i, j, "**/*", "a trailing ** is the same as *, use **/* to recurse")
+	case !whole && n == 2:
+		l.report(i, j, string(star), "** only matches multiple segments when it is a whole segment")
+	case !whole:
+		l.report(i, j, string(star), "redundant stars")
+	}
+	return n
+}
+
+// lintClass checks the bracket expression starting at i and returns its
+// length.
+func (l *linter) lintClass(i int) int {
+	var (
+		pat  = l.pattern
+		j    = i + 1
+		prev rune
+		// the previous character ends a range
+		ranged bool
+	)
+	if j < len(pat) && strings.ContainsRune(l.syntax.Negate, rune(pat[j])) {
+		j++
+	}
+	first := j
+	next := func(j int) (rune, int) {
+		k, n := utf8.DecodeRuneInString(pat[j:])
+		if k == l.syntax.Escape {
+			k, z := utf8.DecodeRuneInString(pat[j+n:])
+			return k, n + z
+		}
+		return k, n
+	}
+	for j < len(pat) {
+		k, n := utf8.DecodeRuneInString(pat[j:])
+		if k == rsquare && (j > first || !l.syntax.Bracket) {
+			break
+		}
+		if k != dash || j == first || strings.HasPrefix(pat[j+n:], string(rsquare)) {
+			prev, n = next(j)
+			ranged = false
+			j += n
+			continue
+		}
+		fix := string([]rune{l.syntax.Escape, dash})
+		if ranged {
+			l.report(j, j+n, fix, "- following a range is literal, escape it")
+			prev, ranged = dash, false
+			j += n
+			continue
+		}
+		hi, z := next(j + n)
+		if !sameKind(prev, hi) {
+			l.report(j, j+n, fix, "range %c-%c mixes different kinds of characters, escape - for a literal dash", prev, hi)
+		}
+		ranged = true
+		j += n + z
+	}
+	return j + 1 - i
+}
+
+func sameKind(a, b rune) bool {
+	switch {
+	case unicode.IsDigit(a):
+		return unicode.IsDigit(b)
+	case unicode.IsUpper(a):
+		return unicode.IsUpper(b)
+	case unicode.IsLower(a):
+		return unicode.IsLower(b)
+	default:
+		return false
+	}
+}
+
+// lintExtGlob checks the extended pattern whose kind is at i and opening
+// parenthesis at j. It returns the number of bytes to skip.
+func (l *linter) lintExtGlob(i, j int) int {
+	end := l.closing(j)
+	if end < 0 || l.pattern[i] == bang {
+		return j + 1 - i
+	}
+	if inner := l.pattern[j+1 : end]; strings.Trim(inner, string(star)) == "" && inner != "" {
+		l.report(i, end+1, string(star), "%s is the same as *", l.pattern[i:end+1])
+		return end + 1 - i
+	}
+	return j + 1 - i
+}
+
+// closing gives the offset of the parenthesis closing the one at i.
+func (l *linter) closing(i int) int {
+	depth := 0
+	for j := i; j < len(l.pattern); {
+		k, n := utf8.DecodeRuneInString(l.pattern[j:])
+		switch k {
+		case l.syntax.Escape:
+			_, z := utf8.DecodeRuneInString(l.pattern[j+n:])
+			n += z
+		case lparen:
+			depth++
+		case rparen:
+			if depth--; depth == 0 {
+				return j
+			}
+		}
+		j += n
+	}
+	return -1
+}
+
+// alternatives reports whether the text between from and to has a pipe
+// outside of nested parentheses.
+func (l *linter) alternatives(from, to int) bool {
+	depth := 0
+	for j := from; j < to; {
+		k, n := utf8.DecodeRuneInString(l.pattern[j:])
+		switch k {
+		case l.syntax.Escape:
+			_, z := utf8.DecodeRuneInString(l.pattern[j+n:])
+			n += z
+		case lparen:
+			depth++
+		case rparen:
+			depth--
+		case pipe:
+			if depth == 0 {
+				return true
+			}
+		}
+		j += n
+	}
+	return false
+}
+
+// lintWords checks the words of an AMQP binding key.
+func (l *linter) lintWords() {
+	var offset int
+	for _, w := range strings.Split(l.pattern, ".") {
+		if w != amqpOne && w != amqpMany {
+			if i := strings.IndexAny(w, amqpOne+amqpMany); i >= 0 {
+				fix := string(w[i])
+				l.report(offset, offset+len(w), fix, "%s is only a wildcard as a whole word", fix)
+			}
+		}
+		offset += len(w) + 1
+	}
+}
@@ -0,0 +1,83 @@
+package glob
+
+import (
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	data := []struct {
+		Pattern  string
+		Dialect  Dialect
+		Relative bool
+		Escape   rune
+		Offset   int
+		Fixed    string
+	}{
+		{Pattern: "src/foo**/*.go", Offset: 7, Fixed: "src/foo*/*.go"},
+		{Pattern: "src/***/*.go", Offset: 4, Fixed: "src/**/*.go"},
+		{Pattern: "src/a***b", Offset: 5, Fixed: "src/a*b"},
+		{Pattern: "src/(foo|bar)/*.go", Offset: 4, Fixed: "src/@(foo|bar)/*.go"},
+		{Pattern: "file[a-c-e]", Offset: 8, Fixed: `file[a-c\-e]`},
+		{Pattern: "file[+-z]", Offset: 6, Fixed: `file[+\-z]`},
+		{Pattern: "src/*(*).go", Offset: 4, Fixed: "src/*.go"},
+		{Pattern: "src/+(**)", Offset: 4, Fixed: "src/*"},
+		{Pattern: "/usr/lib/*.so", Relative: true, Offset: 0, Fixed: "usr/lib/*.so"},
+		{Pattern: "src/(ç|b)", Escape: '§', Offset: 4, Fixed: "src/@(ç|b)"},
+		{Pattern: "src/**", Dialect: Zsh, Offset: 4, Fixed: "src/**/*"},
+		{Pattern: "src/**/*.go", Dialect: FilepathMatch, Offset: 4, Fixed: "src/*/*.go"},
+		{Pattern: "stock.usd*.nyse", Dialect: AMQP, Offset: 6, Fixed: "stock.*.nyse"},
+		{Pattern: "c:\\src\\foo**", Dialect: Windows, Offset: 10, Fixed: "c:\\src\\foo*"},
+	}
+	for _, d := range data {
+		opts := []Option{WithDialect(d.Dialect)}
+		if d.Relative {
+			opts = append(opts, WithRelative())
+		}
+		if d.Escape != 0 {
+			opts = append(opts, WithEscape(d.Escape))
+		}
+		ds := Lint(d.Pattern, opts...)
+		if len(ds) != 1 {
+			t.Errorf("%q: expected one diagnostic, got %d (%v)", d.Pattern, len(ds), ds)
+			continue
+		}
+		if ds[0].Offset != d.Offset {
+			t.Errorf("%q: offset mismatched: want %d, got %d", d.Pattern, d.Offset, ds[0].Offset)
+		}
+		if got := ds[0].Fixed(); got != d.Fixed {
+			t.Errorf("%q: fix mismatched: want %q, got %q", d.Pattern, d.Fixed, got)
+		}
+		if ds := Lint(ds[0].Fixed(), opts...); len(ds) != 0 {
+			t.Errorf("%q: fixed pattern should be clean, got %v", d.Pattern, ds)
+		}
+	}
+}
+
+func TestLintClean(t *testing.T) {
+	patterns := []string{
+		"src/**/*.go",
+		"file[a-z0-9-]",
+		"file[-a-z]",
+		`file[a\-z]`,
+		"*.@(go|c)",
+		`\(a|b\)`,
+		"!(*)",
+		"**",
+		"/usr/lib/*.so",
+	}
+	for _, p := range patterns {
+		if ds := Lint(p); len(ds) != 0 {
+			t.Errorf("%q: unexpected diagnostics %v", p, ds)
+		}
+	}
+}
+
+func TestLintError(t *testing.T) {
+	ds := Lint("src/[abc/*.go")
+	if len(ds) != 1 || !ds[0].Error || ds[0].Offset != 4 {
+		t.Fatalf("expected one error at offset 4, got %v", ds)
+	}
+	if want := "src/[abc/*.go\n    ^"; ds[0].Caret() != want {
+		t.Errorf("caret mismatched: want %q, got %q", want, ds[0].Caret())
+	}
+}
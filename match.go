@@ -4,7 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"unicode"
 	"unicode/utf8"
+
+	"github.com/midbel/glob/ast"
 )
 
 var (
@@ -12,19 +15,53 @@ var (
 	ErrPattern = errors.New("mismatch")
 )
 
+// Matcher matches a path one segment at a time. Match is given the next
+// segment and returns:
+//
+//   - nil and an error (other than ErrMatch) when the segment does not match
+//   - nil and nil when the segment matches and the path is complete
+//   - a Matcher and nil when the segment matches but the returned Matcher
+//     should consume more segments
+//   - a Matcher and ErrMatch when the path is complete but the returned
+//     Matcher can still consume more segments
+//
+// A Matcher for a single segment simply returns nil and a nil error on
+// success. Any implementation can be composed with the compiled ones by Seq,
+// Or, Not and Repeat.
+//
+// The String method of the matchers returned by Compile gives a pattern in
+// the Extended syntax that compiles to an equivalent matcher.
+//
+// Matching never modifies a Matcher: the state of a match is carried by the
+// returned Matcher. The matchers of this package, compiled or combined, can
+// then be shared and used concurrently by multiple goroutines. Custom
+// implementations should follow the same rule.
 type Matcher interface {
 	fmt.Stringer
 
 	Match(string) (Matcher, error)
-	is(string) bool
 }
 
-func Match(str, pattern string) error {
-	m, err := Compile(pattern)
+func Match(str, pattern string, opts ...Option) error {
+	cfg := configure(opts)
+	m, err := cfg.dialect.compile(pattern, cfg)
 	if err != nil {
 		return err
 	}
-	parts := strings.Split(strings.Trim(str, "/"), "/")
+	return matchSegments(m, cfg.dialect.split(str, cfg))
+}
+
+// MatchWith checks that str is matched by m. The options select the dialect
+// used to split str into segments.
+func MatchWith(str string, m Matcher, opts ...Option) error {
+	cfg := configure(opts)
+	return matchSegments(m, cfg.dialect.split(str, cfg))
+}
+
+// matchSegments feeds m with parts and reports whether the whole sequence is
+// matched.
+func matchSegments(m Matcher, parts []string) error {
+	var err error
 	for i := 0; i < len(parts); i++ {
 		if m == nil {
 			return ErrPattern
@@ -34,10 +71,10 @@ func Match(str, pattern string) error {
 			return err
 		}
 	}
-	if m != nil {
-		err = ErrPattern
+	if m != nil && !errors.Is(err, ErrMatch) {
+		return ErrPattern
 	}
-	return err
+	return nil
 }
 
 type simple struct {
@@ -45,7 +82,7 @@ type simple struct {
 }
 
 func (s *simple) String() string {
-	return fmt.Sprintf("simple(%s)", s.pattern)
+	return toExtended(s.pattern)
 }
 
 func (s *simple) Match(str string) (Matcher, error) {
@@ -59,57 +96,32 @@ func (s *simple) Match(str string) (Matcher, error) {
 	return nil, err
 }
 
-func (s *simple) is(str string) bool {
-	return str == s.pattern
-}
-
 type group struct {
 	ms []Matcher
 }
 
 func (g *group) String() string {
-	var buf strings.Builder
-	buf.WriteString("group(")
-	for i, m := range g.ms {
-		if i > 0 {
-			buf.WriteRune(pipe)
-		}
-		buf.WriteString(m.String())
-	}
-	buf.WriteRune(rparen)
-	return buf.String()
+	return string(arobase) + alternatives(g)
 }
 
 func (g *group) Match(str string) (Matcher, error) {
 	var (
-		match bool
-		next  = make([]Matcher, 0, len(g.ms))
+		next = make([]Matcher, 0, len(g.ms))
+		done bool
 	)
 	for _, m := range g.ms {
 		x, err := m.Match(str)
-		if err == nil || errors.Is(err, ErrMatch) {
-			match = true
-			if x != nil {
-				next = append(next, x)
-			}
+		if err != nil && !errors.Is(err, ErrMatch) {
+			continue
+		}
+		if x != nil {
+			next = append(next, x)
+		}
+		if x == nil || errors.Is(err, ErrMatch) {
+			done = true
 		}
 	}
-	if !match {
-		return nil, ErrPattern
-	}
-	var x Matcher
-	switch len(next) {
-	case 0:
-	case 1:
-		x = next[0]
-	default:
-		x = &group{ms: next}
-	}
-	return x, nil
-}
-
-func (g *group) is(_ string) bool {
-	return false
+	return branch(next, done)
 }
 
 type multiple struct {
@@ -118,54 +130,39 @@ type multiple struct {
 
 func (m *multiple) String() string {
 	var buf strings.Builder
-	buf.WriteString("group(")
 	for _, m := range m.ms {
 		buf.WriteString(m.String())
 	}
-	buf.WriteRune(rparen)
 	return buf.String()
 }
 
 func (m *multiple) Match(str string) (Matcher, error) {
-	var offset int
-	for _, m := range m.ms {
-		mr, mok := m.(interface{ more() bool })
-
-		var (
-			multi bool
-			match bool
-			limit = len(str)
-		)
-		for {
-			if _, err := m.Match(str[offset:limit]); err == nil {
-				if mok && mr.more() {
-					multi = true
-					continue
-				}
-				match, offset = true, limit
-				break
-			}
-			k, n := utf8.DecodeLastRuneInString(str[offset:limit])
-			if k == utf8.RuneError {
-				break
-			}
-			limit -= n
-		}
-		if multi {
-			match = multi
+	if !matchSequence(m.ms, str) {
+		return nil, ErrPattern
+	}
+	return nil, nil
+}
+
+// matchSequence reports whether str can be split in as many parts as ms, each
+// part being matched by the matcher at the same position.
+func matchSequence(ms []Matcher, str string) bool {
+	switch len(ms) {
+	case 0:
+		return str == ""
+	case 1:
+		_, err := ms[0].Match(str)
+		return err == nil
+	}
+	for i := 0; i <= len(str); {
+		if _, err := ms[0].Match(str[:i]); err == nil && matchSequence(ms[1:], str[i:]) {
+			return true
 		}
-		if !match {
-			offset = 0
+		if i == len(str) {
 			break
 		}
+		_, n := utf8.DecodeRuneInString(str[i:])
+		i += n
 	}
-	if offset == len(str) {
-		return nil, nil
-	}
-	return nil, ErrPattern
-}
-
-func (m *multiple) is(_ string) bool {
 	return false
 }
 
@@ -173,60 +170,57 @@ type any struct {
 	min   int
 	max   int
 	inner Matcher
-
-	matched int
 }
 
 func (a *any) String() string {
-	return fmt.Sprintf("any(%s)", a.inner)
-}
-
-func (a *any) Match(str string) (Matcher, error) {
 	var (
-		match  int
-		offset int
+		buf   strings.Builder
+		inner = alternatives(a.inner)
 	)
-	for {
-		if offset >= len(str) {
-			break
-		}
-		base := offset
-		_, n := utf8.DecodeRuneInString(str[base:])
-		if n <= 0 {
-			break
-		}
-		offset += n
-		for offset <= len(str) {
-			if _, err := a.inner.Match(str[base:offset]); err == nil {
-				match++
-				break
-			}
-			k, n := utf8.DecodeRuneInString(str[base:])
-			if k == utf8.RuneError {
-				break
-			}
-			offset += n
-		}
-		if a.max > 0 && match >= a.max {
-			break
-		}
-	}
-	ok := offset <= len(str) && match >= a.min
-	if ok && a.max > 0 {
-		ok = match <= a.max
-	}
-	if ok {
-		a.matched++
-		return nil, nil
+	switch {
+	case a.max == 0 && a.min == 0:
+		buf.WriteRune(star)
+		buf.WriteString(inner)
+	case a.max == 0:
+		// n or more is written as n-1 times the inner matcher then +(...)
+		buf.WriteString(strings.Repeat(string(arobase)+inner, a.min-1))
+		buf.WriteRune(plus)
+		buf.WriteString(inner)
+	default:
+		buf.WriteString(strings.Repeat(string(arobase)+inner, a.min))
+		buf.WriteString(strings.Repeat(string(mark)+inner, a.max-a.min))
 	}
-	return nil, ErrPattern
+	return buf.String()
 }
 
-func (a *any) more() bool {
-	return a.matched < a.min || a.max == 0 || a.matched < a.max
+func (a *any) Match(str string) (Matcher, error) {
+	if !a.repeat(str, 0) {
+		return nil, ErrPattern
+	}
+	return nil, nil
 }
 
-func (a *any) is(_ string) bool {
+// repeat reports whether str can be split in non empty parts, each one being
+// matched by the inner matcher, given that count parts have already been
+// matched.
+func (a *any) repeat(str string, count int) bool {
+	if str == "" {
+		if count >= a.min {
+			return true
+		}
+		_, err := a.inner.Match(str)
+		return err == nil
+	}
+	if a.max > 0 && count >= a.max {
+		return false
+	}
+	for i := 0; i < len(str); {
+		_, n := utf8.DecodeRuneInString(str[i:])
+		i += n
+		if _, err := a.inner.Match(str[:i]); err == nil && a.repeat(str[i:], count+1) {
+			return true
+		}
+	}
 	return false
 }
 
@@ -235,7 +229,7 @@ type not struct {
 }
 
 func (n *not) String() string {
-	return fmt.Sprintf("not(%s)", n.inner)
+	return string(bang) + alternatives(n.inner)
 }
 
 func (n *not) Match(str string) (Matcher, error) {
@@ -248,8 +242,21 @@ func (n *not) Match(str string) (Matcher, error) {
 	return nil, err
 }
 
-func (n *not) is(_ string) bool {
-	return false
+type visible struct {
+	inner Matcher
+}
+
+// String gives the pattern of the inner matcher: the Extended syntax has no
+// way to keep wildcards from matching a leading dot.
+func (v *visible) String() string {
+	return v.inner.String()
+}
+
+func (v *visible) Match(str string) (Matcher, error) {
+	if strings.HasPrefix(str, ".") {
+		return nil, ErrPattern
+	}
+	return v.inner.Match(str)
 }
 
 type element struct {
@@ -258,35 +265,216 @@ type element struct {
 }
 
 func (e *element) String() string {
-	return fmt.Sprintf("element(%s)", e.head)
+	if e.next == nil {
+		return e.head.String()
+	}
+	return e.head.String() + string(slash) + e.next.String()
 }
 
 func (e *element) Match(str string) (Matcher, error) {
 	if e == nil || e.head == nil {
 		return nil, ErrPattern
 	}
-	if e.head.is("**") && e.next != nil {
-		if m, err := e.next.Match(str); err == nil {
-			return m, nil
+	var (
+		next []Matcher
+		done bool
+	)
+	if isGlobstar(e.head) {
+		// ** consumes str and stays active for the following segments
+		next = append(next, e)
+		done = e.next == nil || accept(e.next)
+		if e.next == nil {
+			return branch(next, done)
+		}
+		// ** can also match zero segment and let its successor consume str
+		m, err := e.next.Match(str)
+		if err == nil || errors.Is(err, ErrMatch) {
+			if m != nil {
+				next = append(next, m)
+			}
+			done = done || m == nil || errors.Is(err, ErrMatch)
 		}
-		return e, nil
+		return branch(next, done)
 	}
 	m, err := e.head.Match(str)
-	if err == nil || errors.Is(err, ErrMatch) {
-		if m == nil {
-			m = e.next
+	if err != nil && !errors.Is(err, ErrMatch) {
+		return nil, err
+	}
+	if m != nil {
+		next = append(next, &element{
+			head: m,
+			next: e.next,
+		})
+	}
+	if m == nil || errors.Is(err, ErrMatch) {
+		if e.next == nil {
+			done = true
 		} else {
-			m = &element{
-				head: m,
-				next: e.next,
+			next = append(next, e.next)
+			done = accept(e.next)
+		}
+	}
+	return branch(next, done)
+}
+
+// branch merges the matchers that can consume the next segment. done reports
+// whether the input matched so far is accepted: the returned error is then
+// ErrMatch if some matchers can still go on.
+func branch(ms []Matcher, done bool) (Matcher, error) {
+	var next []Matcher
+	for _, m := range ms {
+		if g, ok := m.(*group); ok {
+			for _, m := range g.ms {
+				next = appendMatcher(next, m)
 			}
+		} else {
+			next = appendMatcher(next, m)
 		}
 	}
-	return m, err
+	var m Matcher
+	switch len(next) {
+	case 0:
+		if !done {
+			return nil, ErrPattern
+		}
+		return nil, nil
+	case 1:
+		m = next[0]
+	default:
+		m = &group{ms: next}
+	}
+	if done {
+		return m, ErrMatch
+	}
+	return m, nil
 }
 
-func (e *element) is(str string) bool {
-	return e.head.is(str)
+func appendMatcher(ms []Matcher, m Matcher) []Matcher {
+	for i := range ms {
+		if ms[i] == m {
+			return ms
+		}
+	}
+	return append(ms, m)
+}
+
+// alternatives gives the parenthesized alternatives of m.
+func alternatives(m Matcher) string {
+	g, ok := m.(*group)
+	if !ok {
+		return string(lparen) + m.String() + string(rparen)
+	}
+	var buf strings.Builder
+	buf.WriteRune(lparen)
+	for i, m := range g.ms {
+		if i > 0 {
+			buf.WriteRune(pipe)
+		}
+		buf.WriteString(m.String())
+	}
+	buf.WriteRune(rparen)
+	return buf.String()
+}
+
+// toExtended converts the pattern of a simple matcher to the Extended syntax.
+func toExtended(pat string) string {
+	if pat == "**" {
+		return pat
+	}
+	var buf strings.Builder
+	for i := 0; i < len(pat); {
+		k, n := utf8.DecodeRuneInString(pat[i:])
+		switch {
+		case k == backslash && i+n < len(pat):
+			k, z := utf8.DecodeRuneInString(pat[i+n:])
+			buf.WriteString(quoteSpace(i == 0 || i+n+z == len(pat), k))
+			n += z
+		case k == star:
+			for n < len(pat)-i && pat[i+n] == star {
+				n++
+			}
+			buf.WriteRune(k)
+		case k == mark:
+			buf.WriteRune(k)
+		case k == lsquare:
+			z := classSize(pat[i:])
+			if z > 0 {
+				buf.WriteString(pat[i : i+z])
+				n = z
+				break
+			}
+			fallthrough
+		default:
+			buf.WriteString(quoteSpace(i == 0 || i+n == len(pat), k))
+		}
+		i += n
+	}
+	return buf.String()
+}
+
+// quoteSpace quotes k as a literal, escaping it if it is a space at the
+// border of a pattern where Compile would trim it.
+func quoteSpace(border bool, k rune) string {
+	if border && unicode.IsSpace(k) {
+		return string([]rune{backslash, k})
+	}
+	return ast.QuoteMeta(string(k))
+}
+
+// classSize gives the length of the bracket expression at the beginning of
+// pat or 0 when it is not closed.
+func classSize(pat string) int {
+	i := 1
+	if k, n := utf8.DecodeRuneInString(pat[i:]); k == bang || k == caret {
+		i += n
+	}
+	for i < len(pat) {
+		k, n, esc := classRune(pat[i:])
+		i += n
+		if k == rsquare && !esc {
+			return i
+		}
+	}
+	return 0
+}
+
+// isGlobstar reports whether m matches zero or more segments.
+func isGlobstar(m Matcher) bool {
+	s, ok := m.(*simple)
+	return ok && s.pattern == "**"
+}
+
+// isNil reports whether m is nil or an empty chain of elements.
+func isNil(m Matcher) bool {
+	e, ok := m.(*element)
+	return m == nil || (ok && e == nil)
+}
+
+// accept reports whether m matches an empty sequence of segments.
+func accept(m Matcher) bool {
+	switch m := m.(type) {
+	case *element:
+		if !isGlobstar(m.head) && !accept(m.head) {
+			return false
+		}
+		return m.next == nil || accept(m.next)
+	case *group:
+		for _, m := range m.ms {
+			if accept(m) {
+				return true
+			}
+		}
+	case *complement:
+		return !accept(m.inner)
+	case *intersect:
+		for _, m := range m.ms {
+			if !accept(m) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
 }
 
 func match(str, pat string) (int, bool) {
@@ -378,42 +566,30 @@ func starMatch(str, pat string) (int, int, bool) {
 }
 
 func charsetMatch(char rune, pat string) (int, bool) {
-	next, n := utf8.DecodeRuneInString(pat)
 	var (
 		i      int
 		match  bool
-		prev   rune
-		negate = next == bang || next == caret
+		negate bool
 	)
-	if negate {
+	if k, n := utf8.DecodeRuneInString(pat); k == bang || k == caret {
+		negate = true
 		i += n
 	}
-	for {
-		k, n := utf8.DecodeRuneInString(pat[i:])
+	for i < len(pat) {
+		lo, n, esc := classRune(pat[i:])
 		i += n
-		if k == rsquare || k == utf8.RuneError {
-			prev = k
+		if lo == rsquare && !esc {
 			break
 		}
-		if k == dash {
-			next, n = utf8.DecodeRuneInString(pat[i:])
-			if isRange(prev, next) && char >= prev && char <= next {
-				match = true
-				break
+		hi := lo
+		if k, n := utf8.DecodeRuneInString(pat[i:]); k == dash {
+			if k, z, esc := classRune(pat[i+n:]); z > 0 && (k != rsquare || esc) {
+				hi = k
+				i += n + z
 			}
 		}
-		if match = char == k; match {
-			break
-		}
-		prev = k
-	}
-	if prev != rsquare && prev != utf8.RuneError {
-		for {
-			k, n := utf8.DecodeRuneInString(pat[i:])
-			i += n
-			if k == rsquare || k == utf8.RuneError {
-				break
-			}
+		if !match {
+			match = char >= lo && char <= hi
 		}
 	}
 	if negate {
@@ -422,10 +598,13 @@ func charsetMatch(char rune, pat string) (int, bool) {
 	return i, match
 }
 
-func isRange(prev, next rune) bool {
-	return prev < next && acceptRange(prev) && acceptRange(next)
-}
-
-func acceptRange(b rune) bool {
-	return (b >= 'a' || b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+// classRune decodes the first character of a bracket expression. It reports
+// whether the character has been escaped.
+func classRune(str string) (rune, int, bool) {
+	k, n := utf8.DecodeRuneInString(str)
+	if k != backslash || n >= len(str) {
+		return k, n, false
+	}
+	k, z := utf8.DecodeRuneInString(str[n:])
+	return k, n + z, true
 }
@@ -1,6 +1,8 @@
 package glob
 
 import (
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -39,6 +41,10 @@ func testMatchExtended(t *testing.T) {
 		{Input: "github.com/midbel/glob/README.md.old", Pattern: "g*.@(com|org)/**/*.md!(.old)", Match: false},
 		{Input: "github.com/midbel/glob/README.md", Pattern: "g*.@(com|org)/**/*.!(txt)", Match: true},
 		{Input: "github.com/midbel/glob/README.txt", Pattern: "g*.@(com|org)/**/*.!(txt)", Match: false},
+		{Input: "(github|golang).org", Pattern: "(github|golang).*", Match: true},
+		{Input: "@(foo)", Pattern: `\@(foo)`, Match: true},
+		{Input: "abbbc", Pattern: "a*(b)c", Match: true},
+		{Input: "foo.tar.gz", Pattern: "*.+(tar|gz)", Match: true},
 	}
 	testMatchCases(t, data)
 }
@@ -63,6 +69,9 @@ func testMatchSimple(t *testing.T) {
 		{Input: "src/github.com/midbel/glob/glob.go", Pattern: "**/*go", Match: true},
 		{Input: "src/github.com/midbel/glob/glob.md", Pattern: "src/**/midbel/*/*go", Match: false},
 		{Input: "src/github.com/midbel/glob/glob.md", Pattern: "**/*go", Match: false},
+		{Input: "a/a/b", Pattern: "**/a/b", Match: true},
+		{Input: "src", Pattern: "src/**", Match: true},
+		{Input: "src/github.com/midbel", Pattern: "src/**", Match: true},
 		{Input: "foobar", Pattern: "foo[abc]?r", Match: true},
 		{Input: "foobar", Pattern: "foo[!abc]?r", Match: false},
 		{Input: "foobar", Pattern: "foo[!xyz]?r", Match: true},
@@ -87,3 +96,128 @@ func testMatchCases(t *testing.T, data []MatchCase) {
 		}
 	}
 }
+
+type version struct{}
+
+func (version) String() string {
+	return "version"
+}
+
+func (version) Match(str string) (Matcher, error) {
+	parts := strings.Split(strings.TrimPrefix(str, "v"), ".")
+	if len(parts) != 3 {
+		return nil, ErrPattern
+	}
+	for _, p := range parts {
+		if _, err := strconv.Atoi(p); err != nil {
+			return nil, ErrPattern
+		}
+	}
+	return nil, nil
+}
+
+// mustCompile compiles pattern with the Extended dialect, failing the test
+// on error.
+func mustCompile(t *testing.T, pattern string) Matcher {
+	t.Helper()
+	m, err := Compile(pattern)
+	if err != nil {
+		t.Fatalf("%s: unexpected error: %s", pattern, err)
+	}
+	return m
+}
+
+func TestCompose(t *testing.T) {
+	data := []struct {
+		Name    string
+		Matcher Matcher
+		Input   string
+		Match   bool
+	}{
+		{Name: "seq", Matcher: Seq(mustCompile(t, "releases/**"), version{}), Input: "releases/v1.2.3", Match: true},
+		{Name: "seq", Matcher: Seq(mustCompile(t, "releases/**"), version{}), Input: "releases/stable/1.2.3", Match: true},
+		{Name: "seq", Matcher: Seq(mustCompile(t, "releases/**"), version{}), Input: "releases/v1.2", Match: false},
+		{Name: "seq", Matcher: Seq(mustCompile(t, "releases/**"), version{}, mustCompile(t, "*.tar.gz")), Input: "releases/v1.2.3/app.tar.gz", Match: true},
+		{Name: "or", Matcher: Seq(mustCompile(t, "src"), Or(version{}, mustCompile(t, "latest"))), Input: "src/latest", Match: true},
+		{Name: "or", Matcher: Seq(mustCompile(t, "src"), Or(version{}, mustCompile(t, "latest"))), Input: "src/0.1.0", Match: true},
+		{Name: "or", Matcher: Seq(mustCompile(t, "src"), Or(version{}, mustCompile(t, "latest"))), Input: "src/stable", Match: false},
+		{Name: "not", Matcher: Seq(mustCompile(t, "src"), Not(version{})), Input: "src/stable", Match: true},
+		{Name: "not", Matcher: Seq(mustCompile(t, "src"), Not(version{})), Input: "src/1.0.0", Match: false},
+		{Name: "not", Matcher: Seq(mustCompile(t, "src"), Not(version{})), Input: "src/1.0.0/bin", Match: true},
+		{Name: "repeat", Matcher: Repeat(mustCompile(t, "ab"), 2, 3), Input: "abab", Match: true},
+		{Name: "repeat", Matcher: Repeat(mustCompile(t, "ab"), 2, 3), Input: "ab", Match: false},
+		{Name: "repeat", Matcher: Repeat(mustCompile(t, "ab"), 2, 3), Input: "abababab", Match: false},
+	}
+	for _, d := range data {
+		err := matchSegments(d.Matcher, strings.Split(d.Input, "/"))
+		if d.Match && err != nil {
+			t.Errorf("%s: %s should match %s", d.Name, d.Input, d.Matcher)
+		}
+		if !d.Match && err == nil {
+			t.Errorf("%s: %s should not match %s", d.Name, d.Input, d.Matcher)
+		}
+	}
+}
+
+func TestCombine(t *testing.T) {
+	var (
+		allow = Or(mustCompile(t, "src/**"), mustCompile(t, "docs/*.md"))
+		deny  = Or(mustCompile(t, "**/testdata/**"), mustCompile(t, "**/*.tmp"), mustCompile(t, "src/vendor"))
+		rule  = And(allow, Not(deny))
+	)
+	data := []struct {
+		Matcher Matcher
+		Input   string
+		Match   bool
+	}{
+		{Matcher: rule, Input: "src", Match: true},
+		{Matcher: rule, Input: "src/glob/match.go", Match: true},
+		{Matcher: rule, Input: "src/glob/testdata/file.txt", Match: false},
+		{Matcher: rule, Input: "src/glob/testdata", Match: false},
+		{Matcher: rule, Input: "src/glob/file.tmp", Match: false},
+		{Matcher: rule, Input: "src/vendor", Match: false},
+		{Matcher: rule, Input: "src/vendor/glob", Match: true},
+		{Matcher: rule, Input: "docs/README.md", Match: true},
+		{Matcher: rule, Input: "docs/api/README.md", Match: false},
+		{Matcher: rule, Input: "bin/glob", Match: false},
+		{Matcher: Not(rule), Input: "bin/glob", Match: true},
+		{Matcher: Not(rule), Input: "src/glob/match.go", Match: false},
+		{Matcher: Not(rule), Input: "src/glob/file.tmp", Match: true},
+		{Matcher: And(mustCompile(t, "**/*.go"), mustCompile(t, "src/**")), Input: "src/a/b/c.go", Match: true},
+		{Matcher: And(mustCompile(t, "**/*.go"), mustCompile(t, "src/**")), Input: "lib/a/b/c.go", Match: false},
+		{Matcher: And(mustCompile(t, "a/**/b"), mustCompile(t, "**/b/**")), Input: "a/b", Match: true},
+		{Matcher: And(mustCompile(t, "a/**/b"), mustCompile(t, "**/b/**")), Input: "a/x/b/b", Match: true},
+		{Matcher: And(mustCompile(t, "a/**/b"), mustCompile(t, "**/c/**")), Input: "a/x/b/b", Match: false},
+		{Matcher: Not(Not(mustCompile(t, "a/**"))), Input: "a/b/c", Match: true},
+		{Matcher: Not(Not(mustCompile(t, "a/**"))), Input: "b/a", Match: false},
+	}
+	for i, d := range data {
+		err := MatchWith(d.Input, d.Matcher)
+		if d.Match && err != nil {
+			t.Errorf("%d) %s should match %s", i, d.Input, d.Matcher)
+		}
+		if !d.Match && err == nil {
+			t.Errorf("%d) %s should not match %s", i, d.Input, d.Matcher)
+		}
+	}
+}
+
+func TestComposeString(t *testing.T) {
+	var (
+		a = mustCompile(t, "src/**")
+		b = mustCompile(t, "**/*.go")
+	)
+	data := []struct {
+		Matcher Matcher
+		Want    string
+	}{
+		{Matcher: Not(a), Want: `Not("src/**")`},
+		{Matcher: And(a, b), Want: `And("src/**", "**/*.go")`},
+		{Matcher: Not(And(a, Not(b))), Want: `Not(And("src/**", Not("**/*.go")))`},
+	}
+	for _, d := range data {
+		if got := d.Matcher.String(); got != d.Want {
+			t.Errorf("%q: want %s, got %s", d.Want, d.Want, got)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package glob
+
+import (
+	"strings"
+)
+
+const (
+	mqttOne  = "+"
+	mqttMany = "#"
+
+	mqttMaxLength = 65535
+)
+
+// compileMQTT compiles a topic filter as described in the section 4.7 of the
+// MQTT specification:
+//
+//   - levels are separated by a slash and compared literally
+//   - + matches exactly one level, possibly empty
+//   - # matches its parent level and any number of child levels. It has to be
+//     the last level of the filter
+//   - wildcards in the first level never match a topic starting with $
+func compileMQTT(pattern string, _ *config) (Matcher, error) {
+	if len(pattern) == 0 {
+		return nil, patternError(pattern, 0, "mqtt: empty topic filter")
+	}
+	if len(pattern) > mqttMaxLength {
+		return nil, patternError(pattern, mqttMaxLength, "mqtt: topic filter too long (%d bytes)", len(pattern))
+	}
+	if i := strings.IndexByte(pattern, 0); i >= 0 {
+		return nil, patternError(pattern, i, "mqtt: topic filter contains null character")
+	}
+	var (
+		levels = strings.Split(pattern, "/")
+		ms     = make([]Matcher, 0, len(levels)+1)
+		offset int
+	)
+	for i, lvl := range levels {
+		if i > 0 {
+			offset += len(levels[i-1]) + 1
+		}
+		switch lvl {
+		case mqttOne:
+			ms = append(ms, &simple{pattern: string(star)})
+		case mqttMany:
+			if i < len(levels)-1 {
+				return nil, patternError(pattern, offset, "mqtt: %s should be the last level", mqttMany)
+			}
+			if i == 0 {
+				// # at first level still requires one level to reject $ topics
+				ms = append(ms, &simple{pattern: string(star)})
+			}
+			ms = append(ms, &simple{pattern: "**"})
+		default:
+			if i := strings.IndexAny(lvl, mqttOne+mqttMany); i >= 0 {
+				return nil, patternError(pattern, offset+i, "mqtt: wildcard should occupy an entire level")
+			}
+			ms = append(ms, &simple{pattern: quote(lvl)})
+		}
+	}
+	if levels[0] == mqttOne || levels[0] == mqttMany {
+		ms[0] = &not{inner: &simple{pattern: "$*"}}
+	}
+	return linkMatchers(ms), nil
+}
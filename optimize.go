@@ -0,0 +1,214 @@
+package glob
+
+import (
+	"unicode/utf8"
+)
+
+// optimize rewrites the tree of m into an equivalent tree with less nodes:
+//
+//   - groups with one alternative are replaced by the alternative and
+//     duplicate alternatives are removed
+//   - the common prefix of the alternatives of a group is factored
+//   - nested multiple are flattened and their adjacent simple merged
+//   - consecutive repetitions of the same matcher are folded into one
+//   - *(*), ?(*) and +(*) become *
+//   - chains nested in a chain are spliced and consecutive ** segments become
+//     one
+//
+// Alternatives and repeated matchers are compared by their pattern.
+func optimize(m Matcher) Matcher {
+	switch m := m.(type) {
+	case *element:
+		return optimizeElement(m)
+	case *group:
+		return optimizeGroup(m)
+	case *multiple:
+		return optimizeMultiple(m)
+	case *any:
+		inner := unwrap(optimize(m.inner))
+		if s, ok := inner.(*simple); ok && s.pattern == string(star) {
+			return inner
+		}
+		return &any{min: m.min, max: m.max, inner: inner}
+	case *not:
+		return &not{inner: optimize(m.inner)}
+	case *visible:
+		return &visible{inner: optimize(m.inner)}
+	default:
+		return m
+	}
+}
+
+func optimizeElement(e *element) Matcher {
+	var ms []Matcher
+	for _, m := range appendChain(nil, e) {
+		// chains of the alternatives of a group are spliced in the chain
+		for _, m := range appendChain(nil, optimize(m)) {
+			if n := len(ms); n > 0 && isGlobstar(m) && isGlobstar(ms[n-1]) {
+				continue
+			}
+			ms = append(ms, m)
+		}
+	}
+	return linkMatchers(ms)
+}
+
+func optimizeGroup(g *group) Matcher {
+	var (
+		ms   []Matcher
+		seen = make(map[string]struct{})
+	)
+	for _, m := range g.ms {
+		m = optimize(m)
+		// alternatives of nested groups are lifted in this group
+		xs := []Matcher{m}
+		if g, ok := unwrap(m).(*group); ok {
+			xs = g.ms
+		}
+		for _, x := range xs {
+			str := x.String()
+			if _, ok := seen[str]; ok {
+				continue
+			}
+			seen[str] = struct{}{}
+			ms = append(ms, x)
+		}
+	}
+	if len(ms) == 1 {
+		return ms[0]
+	}
+	if m := factorPrefix(ms); m != nil {
+		return m
+	}
+	return &group{ms: ms}
+}
+
+// factorPrefix rewrites @(ab|ac) into a@(b|c) when all the alternatives in ms
+// are simple matchers of one segment. It returns nil when there is nothing to
+// factor.
+func factorPrefix(ms []Matcher) Matcher {
+	pats := make([]string, 0, len(ms))
+	for _, m := range ms {
+		s, ok := unwrap(m).(*simple)
+		if !ok || isGlobstar(s) {
+			return nil
+		}
+		pats = append(pats, s.pattern)
+	}
+	var n int
+	for {
+		z := atomSize(pats[0][n:])
+		if z == 0 {
+			break
+		}
+		atom := pats[0][n : n+z]
+		for _, p := range pats[1:] {
+			if len(p) < n+z || p[n:n+z] != atom {
+				z = 0
+				break
+			}
+		}
+		if z == 0 {
+			break
+		}
+		n += z
+	}
+	if n == 0 {
+		return nil
+	}
+	var (
+		prefix = &simple{pattern: pats[0][:n]}
+		rest   = make([]Matcher, len(pats))
+	)
+	for i, p := range pats {
+		rest[i] = linkMatchers([]Matcher{&simple{pattern: p[n:]}})
+	}
+	return optimizeMultiple(&multiple{ms: []Matcher{prefix, optimizeGroup(&group{ms: rest})}})
+}
+
+func optimizeMultiple(m *multiple) Matcher {
+	var ms []Matcher
+	for _, x := range m.ms {
+		x = unwrap(optimize(x))
+		xs := []Matcher{x}
+		if m, ok := x.(*multiple); ok {
+			xs = m.ms
+		}
+		for _, x := range xs {
+			ms = appendPart(ms, x)
+		}
+	}
+	switch len(ms) {
+	case 0:
+		return &simple{}
+	case 1:
+		if isGlobstar(ms[0]) {
+			// ** only spans multiple segments when it is a whole segment
+			return &simple{pattern: string(star)}
+		}
+		return ms[0]
+	default:
+		return &multiple{ms: ms}
+	}
+}
+
+// appendPart appends x to the parts of a multiple merging it with the last
+// part when possible.
+func appendPart(ms []Matcher, x Matcher) []Matcher {
+	if s, ok := x.(*simple); ok && s.pattern == "" {
+		return ms
+	}
+	n := len(ms)
+	if n == 0 {
+		return append(ms, x)
+	}
+	switch last := ms[n-1].(type) {
+	case *simple:
+		if s, ok := x.(*simple); ok {
+			ms[n-1] = &simple{pattern: last.pattern + s.pattern}
+			return ms
+		}
+	case *any:
+		a, ok := x.(*any)
+		if !ok || a.inner.String() != last.inner.String() {
+			break
+		}
+		max := last.max + a.max
+		if last.max == 0 || a.max == 0 {
+			max = 0
+		}
+		ms[n-1] = &any{min: last.min + a.min, max: max, inner: last.inner}
+		return ms
+	}
+	return append(ms, x)
+}
+
+// unwrap returns the head of a chain of one element since it matches the
+// same segments as the chain. ** is kept in its element to not lose its
+// meaning.
+func unwrap(m Matcher) Matcher {
+	e, ok := m.(*element)
+	if !ok || e.next != nil || isGlobstar(e.head) {
+		return m
+	}
+	return e.head
+}
+
+// atomSize gives the length of the first character, escaped character or
+// bracket expression of the pattern of a simple matcher.
+func atomSize(pat string) int {
+	if pat == "" {
+		return 0
+	}
+	k, n := utf8.DecodeRuneInString(pat)
+	switch {
+	case k == backslash && n < len(pat):
+		_, z := utf8.DecodeRuneInString(pat[n:])
+		return n + z
+	case k == lsquare:
+		if z := classSize(pat); z > 0 {
+			return z
+		}
+	}
+	return n
+}
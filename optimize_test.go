@@ -0,0 +1,60 @@
+package glob
+
+import (
+	"testing"
+)
+
+func TestOptimize(t *testing.T) {
+	data := []struct {
+		Pattern string
+		Want    string
+		Inputs  []string
+	}{
+		{Pattern: "@(a|a)", Want: "a", Inputs: []string{"a", "aa", ""}},
+		{Pattern: "*(*).go", Want: "*.go", Inputs: []string{"main.go", ".go", "main.c"}},
+		{Pattern: "+(*)", Want: "*", Inputs: []string{"", "a"}},
+		{Pattern: "src/**/**/*.go", Want: "src/**/*.go", Inputs: []string{"src/main.go", "src/a/b/main.go", "main.go"}},
+		{Pattern: "@(foobar|foobaz)", Want: "fooba@(r|z)", Inputs: []string{"foobar", "foobaz", "fooba", "foobay"}},
+		{Pattern: "@(foo|foobar)", Want: "foo@(|bar)", Inputs: []string{"foo", "foobar", "fooba"}},
+		{Pattern: "@(*.go|*.c)", Want: "*.@(go|c)", Inputs: []string{"main.go", "main.c", "main.h"}},
+		{Pattern: "@([ab]x|[ab]y)", Want: "[ab]@(x|y)", Inputs: []string{"ax", "by", "cx", "az"}},
+		{Pattern: "a@(b)c", Want: "abc", Inputs: []string{"abc", "ac"}},
+		{Pattern: "@(a|@(b|c))", Want: "@(a|b|c)", Inputs: []string{"a", "b", "c", "d"}},
+		{Pattern: "@(a/b|a/b)/c", Want: "a/b/c", Inputs: []string{"a/b/c", "a/c"}},
+		{Pattern: "@(a/**|a/**)/**/b", Want: "a/**/b", Inputs: []string{"a/b", "a/x/y/b", "b"}},
+		{Pattern: "?(x)?(x)?(x)", Want: "?(x)?(x)?(x)", Inputs: []string{"", "x", "xxx", "xxxx", "y"}},
+		{Pattern: "+(x)*(x)", Want: "+(x)", Inputs: []string{"", "x", "xxx", "y"}},
+	}
+	for _, d := range data {
+		m, err := Compile(d.Pattern)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", d.Pattern, err)
+			continue
+		}
+		if got := m.String(); got != d.Want {
+			t.Errorf("%q: optimized pattern mismatched: want %q, got %q", d.Pattern, d.Want, got)
+		}
+		raw, _ := Extended.build(d.Pattern, configure(nil))
+		for _, i := range d.Inputs {
+			want := MatchWith(i, raw) == nil
+			if got := MatchWith(i, m) == nil; got != want {
+				t.Errorf("%q: optimized matcher disagrees on %q (want %t)", d.Pattern, i, want)
+			}
+		}
+	}
+}
+
+func TestOptimizeRepeat(t *testing.T) {
+	m, err := Extended.build("?(x)?(x)?(x)", configure(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	e, ok := optimize(m).(*element)
+	if !ok {
+		t.Fatalf("unexpected matcher %T", m)
+	}
+	a, ok := e.head.(*any)
+	if !ok || a.min != 0 || a.max != 3 {
+		t.Errorf("?(x) chain not folded: %#v", e.head)
+	}
+}
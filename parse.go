@@ -1,19 +1,67 @@
 package glob
 
 import (
-	"errors"
 	"fmt"
-	"io"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/midbel/glob/ast"
 )
 
-func Compile(pattern string) (Matcher, error) {
-	pattern = strings.TrimSpace(pattern)
-	if len(pattern) == 0 {
-		return nil, fmt.Errorf("empty pattern")
+// Compile parses pattern and returns a Matcher for it. A malformed pattern is
+// reported by a *PatternError. The returned Matcher is safe for concurrent
+// use.
+func Compile(pattern string, opts ...Option) (Matcher, error) {
+	cfg := configure(opts)
+	return cfg.dialect.compile(pattern, cfg)
+}
+
+// Format returns pattern in the canonical form of the Extended syntax: the
+// runs of stars are reduced to one star, the escape characters are only kept
+// where they are needed and the continuation lines are joined. Spaces
+// surrounding the pattern are removed while the ones inside the pattern,
+// including in groups, are kept since they are significant.
+func Format(pattern string) (string, error) {
+	n, err := ast.Parse(pattern)
+	if err != nil {
+		return "", err
+	}
+	str := n.String()
+	if str == "" {
+		return "", patternError(pattern, 0, "empty pattern")
+	}
+	// spaces at the borders have to be escaped to not be trimmed by Compile
+	if k, _ := utf8.DecodeRuneInString(str); unicode.IsSpace(k) {
+		str = string(backslash) + str
+	}
+	if k, n := utf8.DecodeLastRuneInString(str); unicode.IsSpace(k) && len(str) > n+1 {
+		str = str[:len(str)-n] + string(backslash) + str[len(str)-n:]
+	}
+	return str, nil
+}
+
+func compileExtended(pattern string, cfg *config) (Matcher, error) {
+	syn := cfg.syntax(extendedSyntax)
+	from, to := ast.TrimSpace(pattern, syn.Escape)
+	return parse(pattern, from, to, syn)
+}
+
+func compileSyntax(pattern string, cfg *syntax) (Matcher, error) {
+	return parse(pattern, 0, len(pattern), cfg)
+}
+
+// parse parses the part of pattern between from and to. The offsets of the
+// errors are given relative to the beginning of pattern.
+func parse(pattern string, from, to int, cfg *syntax) (Matcher, error) {
+	if from >= to {
+		return nil, patternError(pattern, from, "empty pattern")
+	}
+	p, err := ast.ParseRange(pattern, from, to, cfg.Syntax)
+	if err != nil {
+		return nil, err
 	}
-	pattern = strings.ReplaceAll(pattern, "\r\n", "\n")
-	return parseReader(strings.NewReader(pattern))
+	return compileTree(p, cfg), nil
 }
 
 func Debug(m Matcher) {
@@ -35,119 +83,183 @@ const (
 	pipe      = '|'
 	arobase   = '@'
 	plus      = '+'
+	lbrace    = '{'
+	rbrace    = '}'
+	comma     = ','
+	hash      = '#'
 	newline   = '\n'
 	tab       = '\t'
 	space     = ' '
 )
 
-func parseReader(r *strings.Reader) (Matcher, error) {
+// compileTree builds the matchers of the segments of p.
+func compileTree(p *ast.Pattern, cfg *syntax) Matcher {
+	ms := make([]Matcher, 0, len(p.Segments))
+	for _, s := range p.Segments {
+		ms = append(ms, compileSegment(s, cfg))
+	}
+	if n := len(ms); cfg.Zsh && n > 0 && isGlobstar(ms[n-1]) {
+		// zsh only recurses with **/: a trailing ** is the same as *
+		ms[n-1] = &simple{pattern: string(star)}
+	}
+	return linkMatchers(ms)
+}
+
+func compileSegment(s *ast.Segment, cfg *syntax) Matcher {
 	var (
 		buf strings.Builder
-		ms  []Matcher
 		cs  []Matcher
 	)
+	flush := func() {
+		if buf.Len() > 0 {
+			cs = append(cs, &simple{pattern: buf.String()})
+			buf.Reset()
+		}
+	}
+	for _, n := range s.Nodes {
+		if str, ok := compileText(n); ok {
+			buf.WriteString(str)
+			continue
+		}
+		flush()
+		cs = append(cs, compileNode(n, cfg))
+	}
+	flush()
+
+	m := mergeMatchers(cs)
+	if m == nil {
+		m = &simple{}
+	}
+	if !globstarSegment(s) && isGlobstar(m) {
+		m = &simple{pattern: string(star)}
+	}
+	if s.Negate {
+		m = &not{inner: m}
+	}
+	if !cfg.dotglob && !isGlobstar(m) {
+		m = hideDot(m)
+	}
+	return m
+}
+
+// hideDot keeps the wildcards of m from matching a leading dot unless m
+// starts with a dot. The alternatives of a group being compiled with the same
+// rule, a leading group is distributed over the matchers following it so that
+// each alternative decides on its own.
+func hideDot(m Matcher) Matcher {
+	switch x := m.(type) {
+	case *group:
+		return m
+	case *multiple:
+		if g, ok := x.ms[0].(*group); ok {
+			if ms, ok := distribute(g, x.ms[1:]); ok {
+				return &group{ms: ms}
+			}
+		}
+	}
+	if leadingDot(m) {
+		return m
+	}
+	return &visible{inner: m}
+}
 
-	for {
-		k, _, err := r.ReadRune()
-		if err != nil {
-			if errors.Is(err, io.EOF) {
-				break
+// distribute gives the alternatives of g each followed by rest. It fails when
+// an alternative spans several segments.
+func distribute(g *group, rest []Matcher) ([]Matcher, bool) {
+	ms := make([]Matcher, len(g.ms))
+	for i, m := range g.ms {
+		if e, ok := m.(*element); ok {
+			if e.next != nil {
+				return nil, false
 			}
-			return nil, err
+			m = e.head
+		}
+		if v, ok := m.(*visible); ok {
+			m = v.inner
+		}
+		var cs []Matcher
+		if x, ok := m.(*multiple); ok {
+			cs = append(cs, x.ms...)
+		} else {
+			cs = append(cs, m)
 		}
-		if k == pipe || k == rparen {
-			r.UnreadRune()
-			break
+		cs = append(cs, rest...)
+		ms[i] = &element{head: hideDot(mergeMatchers(cs))}
+	}
+	return ms, true
+}
+
+func globstarSegment(s *ast.Segment) bool {
+	if len(s.Nodes) != 1 {
+		return false
+	}
+	w, ok := s.Nodes[0].(*ast.Wildcard)
+	return ok && w.Kind == ast.Globstar
+}
+
+// compileText gives the pattern of a simple matcher for the nodes that can
+// be merged into one.
+func compileText(n ast.Node) (string, bool) {
+	switch n := n.(type) {
+	case *ast.Literal:
+		return quote(n.Value), true
+	case *ast.Wildcard:
+		return n.Kind.String(), true
+	case *ast.Class:
+		var buf strings.Builder
+		buf.WriteRune(lsquare)
+		if n.Negate {
+			buf.WriteRune(bang)
 		}
-		switch k {
-		case backslash:
-			if k, _, _ = r.ReadRune(); k != newline {
-				buf.WriteRune(backslash)
-			} else {
-				for {
-					k, _, _ = r.ReadRune()
-					if k != space && k != tab && k != newline {
-						break
-					}
-				}
+		for _, r := range n.Ranges {
+			buf.WriteString(quoteClass(r.Lo))
+			if r.Hi != r.Lo {
+				buf.WriteRune(dash)
+				buf.WriteString(quoteClass(r.Hi))
 			}
-			r.UnreadRune()
+		}
+		buf.WriteRune(rsquare)
+		return buf.String(), true
+	default:
+		return "", false
+	}
+}
+
+func compileNode(n ast.Node, cfg *syntax) Matcher {
+	switch n := n.(type) {
+	case *ast.ExtGlob:
+		g := compileAlts(n.Alts, cfg)
+		switch n.Kind {
 		case arobase:
-			if z, _, _ := r.ReadRune(); z != lparen {
-				buf.WriteRune(k)
-				r.UnreadRune()
-				continue
-			}
-			if buf.Len() > 0 {
-				cs = append(cs, &simple{pattern: buf.String()})
-				buf.Reset()
-			}
-			g, err := parseGroup(r)
-			if err != nil {
-				return nil, err
-			}
-			cs = append(cs, g)
+			return g
 		case bang:
-			if z, _, _ := r.ReadRune(); z != lparen {
-				buf.WriteRune(k)
-				r.UnreadRune()
-				continue
-			}
-			r.UnreadRune()
-			if buf.Len() > 0 {
-				cs = append(cs, &simple{pattern: buf.String()})
-				buf.Reset()
-			}
-			n, err := parseNot(r)
-			if err != nil {
-				return nil, err
-			}
-			cs = append(cs, n)
-		case plus, star, mark:
-			if z, _, _ := r.ReadRune(); z != lparen {
-				buf.WriteRune(k)
-				r.UnreadRune()
-				continue
-			}
-			if buf.Len() > 0 {
-				cs = append(cs, &simple{pattern: buf.String()})
-				buf.Reset()
-			}
-			a, err := parseAny(r, k)
-			if err != nil {
-				return nil, err
-			}
-			cs = append(cs, a)
-		case slash:
-			if buf.Len() > 0 {
-				cs = append(cs, &simple{pattern: buf.String()})
-				buf.Reset()
-			}
-			if m := mergeMatchers(cs); m != nil {
-				ms = append(ms, m)
-			}
-			cs = cs[:0]
+			return &not{inner: g}
 		default:
-			buf.WriteRune(k)
+			return newAny(g, n.Kind)
 		}
+	case *ast.Group:
+		return compileAlts(n.Alts, cfg)
+	case *ast.Repeat:
+		m, ok := compileText(n.Node)
+		if ok {
+			return &any{min: n.Min, inner: &simple{pattern: m}}
+		}
+		return &any{min: n.Min, inner: compileNode(n.Node, cfg)}
+	default:
+		return nil
 	}
-	if buf.Len() > 0 {
-		cs = append(cs, &simple{pattern: buf.String()})
-	}
-	if m := mergeMatchers(cs); m != nil {
-		ms = append(ms, m)
-	}
-	return linkMatchers(ms), nil
 }
 
-func parseAny(r *strings.Reader, k rune) (Matcher, error) {
-	m, err := parseGroup(r)
-	if err != nil {
-		return nil, err
+func compileAlts(alts []*ast.Pattern, cfg *syntax) Matcher {
+	var grp group
+	for _, a := range alts {
+		grp.ms = append(grp.ms, compileTree(a, cfg))
 	}
-	var a any
+	return &grp
+}
 
-	a.inner = m
+func newAny(m Matcher, k rune) Matcher {
+	a := any{inner: m}
 	switch k {
 	default:
 		a.min, a.max = 0, 0
@@ -156,48 +268,30 @@ func parseAny(r *strings.Reader, k rune) (Matcher, error) {
 	case plus:
 		a.min, a.max = 1, 0
 	}
-	return &a, nil
+	return &a
 }
 
-func parseNot(r *strings.Reader) (Matcher, error) {
-	k, _, err := r.ReadRune()
-	if err != nil {
-		return nil, err
-	}
-	if k != lparen {
-		return nil, fmt.Errorf("expecting (, got %c (position: %d)", k, int(r.Size())-r.Len())
-	}
-	m, err := parseGroup(r)
-	if err != nil {
-		return nil, err
+// quoteClass escapes k when it has a special meaning in a bracket expression.
+func quoteClass(k rune) string {
+	switch k {
+	case backslash, rsquare, dash, bang, caret:
+		return string([]rune{backslash, k})
+	default:
+		return string(k)
 	}
-	return &not{inner: m}, nil
 }
 
-func parseGroup(r *strings.Reader) (Matcher, error) {
-	var grp group
-
-Loop:
-	for {
-		m, err := parseReader(r)
-		if err != nil {
-			return nil, err
-		}
-
-		grp.ms = append(grp.ms, m)
-		k, _, err := r.ReadRune()
-		if err != nil {
-			return nil, err
-		}
-		switch k {
-		case pipe:
-		case rparen:
-			break Loop
-		default:
-			return nil, fmt.Errorf("unexpected character %c", k)
-		}
+// leadingDot reports whether m explicitly matches a dot at the beginning of
+// a segment.
+func leadingDot(m Matcher) bool {
+	switch m := m.(type) {
+	case *simple:
+		return strings.HasPrefix(m.pattern, ".")
+	case *multiple:
+		return len(m.ms) > 0 && leadingDot(m.ms[0])
+	default:
+		return false
 	}
-	return &grp, nil
 }
 
 func linkMatchers(ms []Matcher) Matcher {
@@ -255,6 +349,14 @@ func debug(m Matcher, level int) {
 		fmt.Printf("%snot(\n", indent)
 		debug(m.inner, level+1)
 		fmt.Printf("%s)\n", indent)
+	case *automaton:
+		fmt.Printf("%sautomaton(\n", indent)
+		debug(m.src, level+1)
+		fmt.Printf("%s)\n", indent)
+	case *visible:
+		fmt.Printf("%svisible(\n", indent)
+		debug(m.inner, level+1)
+		fmt.Printf("%s)\n", indent)
 	case *group:
 		fmt.Printf("%sgroup(\n", indent)
 		for i, m := range m.ms {
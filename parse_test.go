@@ -1,6 +1,7 @@
 package glob
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -18,6 +19,16 @@ func TestCompile(t *testing.T) {
 		{Pattern: "?(ab|cd)", Fail: false},
 		{Pattern: "github.com/(midbel/glob|midbel/cbor)/**/*.!(go)", Fail: false},
 		{Pattern: "git(hub|lab).(com|org)/(midbel|busoc)", Fail: false},
+		{Pattern: "[abc", Fail: true},
+		{Pattern: "[]", Fail: true},
+		{Pattern: "foo\\", Fail: true},
+		{Pattern: "[z-a]", Fail: true},
+		{Pattern: "[a-]", Fail: false},
+		{Pattern: "foo)", Fail: true},
+		{Pattern: "+(ab|cd", Fail: true},
+		{Pattern: "*.!(go", Fail: true},
+		{Pattern: "[!\\]]", Fail: false},
+		{Pattern: "[!]", Fail: true},
 	}
 	for i, d := range data {
 		_, err := Compile(d.Pattern)
@@ -29,3 +40,115 @@ func TestCompile(t *testing.T) {
 		}
 	}
 }
+
+func TestPatternError(t *testing.T) {
+	data := []struct {
+		Pattern string
+		Options []Option
+		Offset  int
+		Caret   string
+	}{
+		{Pattern: "src/[abc/*.go", Offset: 4, Caret: "src/[abc/*.go\n    ^"},
+		{Pattern: "  src/[z-a]", Offset: 7, Caret: "  src/[z-a]\n       ^"},
+		{Pattern: "src/foo\\", Offset: 7, Caret: "src/foo\\\n       ^"},
+		{Pattern: "src/*.go)", Offset: 8, Caret: "src/*.go)\n        ^"},
+		{Pattern: "src/\\\n\t*.+(go|c", Offset: 9, Caret: "\t*.+(go|c\n\t  ^"},
+		{Pattern: "é/[", Offset: 3, Caret: "é/[\n  ^"},
+		{Pattern: "a/#/b", Options: []Option{WithDialect(MQTT)}, Offset: 2},
+		{Pattern: "a/b+", Options: []Option{WithDialect(MQTT)}, Offset: 3},
+		{Pattern: "www.*.com", Options: []Option{WithDialect(Hostname)}, Offset: 4},
+		{Pattern: `C:\src\[abc`, Options: []Option{WithDialect(Windows)}, Offset: 7},
+		{Pattern: "a/[b/c", Options: []Option{WithDialect(FilepathMatch)}, Offset: 2},
+		{Pattern: "[]", Options: []Option{WithDialect(Bash)}, Offset: 0},
+		{Pattern: "[]a]/[]", Options: []Option{WithDialect(Bash)}, Offset: 5},
+		{Pattern: "//", Offset: 0},
+		{Pattern: "/", Options: []Option{WithDialect(Bash)}, Offset: 0},
+		{Pattern: " /", Options: []Option{WithDialect(Hostname)}, Offset: 0},
+	}
+	for _, d := range data {
+		_, err := Compile(d.Pattern, d.Options...)
+		var e *PatternError
+		if !errors.As(err, &e) {
+			t.Errorf("%q: expected PatternError, got %v", d.Pattern, err)
+			continue
+		}
+		if e.Pattern != d.Pattern {
+			t.Errorf("%q: pattern mismatched: got %q", d.Pattern, e.Pattern)
+		}
+		if e.Offset != d.Offset {
+			t.Errorf("%q: offset mismatched: want %d, got %d (%s)", d.Pattern, d.Offset, e.Offset, e.Msg)
+		}
+		if d.Caret != "" && e.Caret() != d.Caret {
+			t.Errorf("%q: caret mismatched: want %q, got %q", d.Pattern, d.Caret, e.Caret())
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	data := []struct {
+		Pattern string
+		Want    string
+	}{
+		{Pattern: "f****r", Want: "f*r"},
+		{Pattern: "src/**/*.go", Want: "src/**/*.go"},
+		{Pattern: "src/a**/*.go", Want: "src/a*/*.go"},
+		{Pattern: `\f\o\o\.\t\x\t`, Want: "foo.txt"},
+		{Pattern: `\*\?\[`, Want: `\*\?\[`},
+		{Pattern: "  *.@(go|c)  ", Want: "*.@(go|c)"},
+		{Pattern: "@( go | c )", Want: "@( go | c )"},
+		{Pattern: "\\ foo\\ ", Want: "\\ foo\\ "},
+		{Pattern: "src/\\\n    **/\\\n    *.go", Want: "src/**/*.go"},
+		{Pattern: "[\\a-\\c\\_]", Want: "[a-c_]"},
+		{Pattern: "[\\!a\\-]", Want: "[\\!a-]"},
+		{Pattern: "(github|golang).com", Want: `\(github\|golang\).com`},
+		{Pattern: "*.!(*~*~|bak)", Want: "*.!(*~*~|bak)"},
+	}
+	for _, d := range data {
+		got, err := Format(d.Pattern)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", d.Pattern, err)
+			continue
+		}
+		if got != d.Want {
+			t.Errorf("%q: format mismatched: want %q, got %q", d.Pattern, d.Want, got)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	data := []struct {
+		Pattern string
+		Inputs  []string
+	}{
+		{Pattern: "src/**/*.go", Inputs: []string{"src/main.go", "src/a/b/main.go", "main.go", "src/a/b/main.c"}},
+		{Pattern: "f****r", Inputs: []string{"fr", "foobar", "foo/bar"}},
+		{Pattern: "g*.@(com|org)/@(foo/toml|bar/glob)/*md", Inputs: []string{"github.com/foo/toml/README.md", "github.com/foo/glob/README.md"}},
+		{Pattern: "*.+(tar|gz)", Inputs: []string{"a.tar", "a.targz", "a.zip"}},
+		{Pattern: "?(a|b)*(c)!(d|e)", Inputs: []string{"acf", "ad", "cccz", "b"}},
+		{Pattern: `\(a\|b\)/\*/\ x`, Inputs: []string{"(a|b)/*/ x", "a/*/ x"}},
+		{Pattern: "[!a-c\\]]x", Inputs: []string{"dx", "ax", "]x"}},
+		{Pattern: "@(|a/b)c", Inputs: []string{"c", "a/bc", "ac"}},
+	}
+	for _, d := range data {
+		m, err := Compile(d.Pattern)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", d.Pattern, err)
+			continue
+		}
+		str := m.String()
+		x, err := Compile(str)
+		if err != nil {
+			t.Errorf("%q: %q does not compile: %s", d.Pattern, str, err)
+			continue
+		}
+		if x.String() != str {
+			t.Errorf("%q: string mismatched: %q != %q", d.Pattern, x.String(), str)
+		}
+		for _, i := range d.Inputs {
+			want := MatchWith(i, m) == nil
+			if got := MatchWith(i, x) == nil; got != want {
+				t.Errorf("%q: %q and %q disagree on %s", d.Pattern, d.Pattern, str, i)
+			}
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package glob
+
+import (
+	"math/bits"
+	"strings"
+	"unsafe"
+)
+
+// Pattern is a compiled pattern. Unlike the Matcher it embeds, it matches a
+// whole path at once and, except for the Hostname and Windows dialects that
+// normalize their input, without allocating memory once the automata of the
+// pattern have seen the characters of the input.
+//
+// A Pattern is safe for concurrent use.
+type Pattern struct {
+	Matcher
+
+	cfg  *config
+	prog *program
+	// separator and trimming of the input for the dialects splitting it on a
+	// single byte
+	sep  byte
+	trim bool
+}
+
+// CompilePattern compiles pattern into a Pattern.
+func CompilePattern(pattern string, opts ...Option) (*Pattern, error) {
+	cfg := configure(opts)
+	m, err := cfg.dialect.compile(pattern, cfg)
+	if err != nil {
+		return nil, err
+	}
+	p := Pattern{
+		Matcher: m,
+		cfg:     cfg,
+		prog:    newProgram(m),
+	}
+	switch cfg.dialect {
+	case Extended, Bash, Zsh, Doublestar, GoPackage:
+		p.sep, p.trim = slash, true
+	case MQTT, FilepathMatch:
+		p.sep = slash
+	case AMQP:
+		p.sep = '.'
+	}
+	return &p, nil
+}
+
+// MatchString reports whether str is matched by the pattern.
+func (p *Pattern) MatchString(str string) bool {
+	switch {
+	case p.prog == nil:
+		return matchSegments(p.Matcher, p.cfg.dialect.split(str, p.cfg)) == nil
+	case p.sep == 0:
+		return p.prog.matchParts(p.cfg.dialect.split(str, p.cfg))
+	}
+	if p.trim {
+		str = strings.Trim(str, string(p.sep))
+	}
+	return p.prog.match(str, p.sep)
+}
+
+// MatchBytes is like MatchString for a slice of bytes.
+func (p *Pattern) MatchBytes(b []byte) bool {
+	// the string does not outlive the call and b is never modified
+	return p.MatchString(unsafe.String(unsafe.SliceData(b), len(b)))
+}
+
+const maxNodes = 256
+
+// stateSet holds the indices of the active nodes of a program.
+type stateSet [maxNodes / 64]uint64
+
+func (s *stateSet) add(i int) {
+	s[i/64] |= 1 << (i % 64)
+}
+
+func (s *stateSet) has(i int) bool {
+	return s[i/64]&(1<<(i%64)) != 0
+}
+
+func (s *stateSet) union(o *stateSet) {
+	for i := range s {
+		s[i] |= o[i]
+	}
+}
+
+func (s *stateSet) empty() bool {
+	for i := range s {
+		if s[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+type nodeKind uint8
+
+const (
+	nodeFinal    nodeKind = iota // end of the pattern
+	nodeSegment                  // matches one segment
+	nodeGlobstar                 // matches zero or more segments
+	nodeSplit                    // alternatives of a group
+)
+
+type node struct {
+	kind nodeKind
+	m    Matcher
+	next int
+	alts []int
+}
+
+// program is a NFA whose transitions consume whole segments. The matchers
+// of the segments are the automata of the compiled pattern. The active
+// nodes are kept in a fixed size set so that matching does not allocate.
+type program struct {
+	nodes []node
+	// closure gives for each node the set of nodes reachable from it
+	// without consuming a segment
+	closure []stateSet
+	start   int
+}
+
+// newProgram returns nil when m contains matchers that can not be part of a
+// program or is too large.
+func newProgram(m Matcher) *program {
+	var p program
+	p.nodes = append(p.nodes, node{kind: nodeFinal})
+	start, ok := p.chain(m, 0)
+	if !ok || len(p.nodes) > maxNodes {
+		return nil
+	}
+	p.start = start
+	p.closure = make([]stateSet, len(p.nodes))
+	for i := range p.nodes {
+		p.close(&p.closure[i], i)
+	}
+	return &p
+}
+
+// chain adds the nodes of m continuing to next and returns the first one.
+func (p *program) chain(m Matcher, next int) (int, bool) {
+	e, ok := m.(*element)
+	if !ok {
+		return p.head(m, next)
+	}
+	if e.next != nil {
+		if next, ok = p.chain(e.next, next); !ok {
+			return 0, false
+		}
+	}
+	return p.head(e.head, next)
+}
+
+func (p *program) head(m Matcher, next int) (int, bool) {
+	n := node{
+		kind: nodeSegment,
+		m:    m,
+		next: next,
+	}
+	switch x := m.(type) {
+	case *element:
+		return p.chain(x, next)
+	case *group:
+		n.kind = nodeSplit
+		for _, m := range x.ms {
+			i, ok := p.chain(m, next)
+			if !ok {
+				return 0, false
+			}
+			n.alts = append(n.alts, i)
+		}
+	case *simple:
+		if isGlobstar(x) {
+			n.kind = nodeGlobstar
+		}
+	case *automaton:
+	default:
+		// the other matchers may span multiple segments
+		return 0, false
+	}
+	p.nodes = append(p.nodes, n)
+	return len(p.nodes) - 1, true
+}
+
+func (p *program) close(set *stateSet, i int) {
+	if set.has(i) {
+		return
+	}
+	set.add(i)
+	switch n := p.nodes[i]; n.kind {
+	case nodeGlobstar:
+		p.close(set, n.next)
+	case nodeSplit:
+		for _, i := range n.alts {
+			p.close(set, i)
+		}
+	}
+}
+
+func (p *program) match(str string, sep byte) bool {
+	cur := p.closure[p.start]
+	for {
+		i := strings.IndexByte(str, sep)
+		if i < 0 {
+			cur = p.step(&cur, str)
+			break
+		}
+		cur = p.step(&cur, str[:i])
+		if cur.empty() {
+			return false
+		}
+		str = str[i+1:]
+	}
+	return cur.has(0)
+}
+
+func (p *program) matchParts(parts []string) bool {
+	cur := p.closure[p.start]
+	for _, str := range parts {
+		cur = p.step(&cur, str)
+	}
+	return cur.has(0)
+}
+
+// step gives the nodes active after the segment str.
+func (p *program) step(cur *stateSet, str string) stateSet {
+	var next stateSet
+	for w, set := range cur {
+		for set != 0 {
+			var (
+				b = bits.TrailingZeros64(set)
+				i = w*64 + b
+				n = &p.nodes[i]
+			)
+			set &^= 1 << b
+			switch n.kind {
+			case nodeGlobstar:
+				next.union(&p.closure[i])
+			case nodeSegment:
+				if segmentMatch(n.m, str) {
+					next.union(&p.closure[n.next])
+				}
+			}
+		}
+	}
+	return next
+}
+
+func segmentMatch(m Matcher, str string) bool {
+	if a, ok := m.(*automaton); ok {
+		return a.match(str)
+	}
+	_, err := m.Match(str)
+	return err == nil
+}
@@ -0,0 +1,184 @@
+package glob
+
+import (
+	"testing"
+)
+
+var patternCases = []struct {
+	Pattern string
+	Dialect Dialect
+	Inputs  []string
+}{
+	{
+		Pattern: "src/**/*.go",
+		Inputs:  []string{"src/glob.go", "src/a/b/glob.go", "/src/a/glob.go/", "src", "src/a/glob.c", "lib/glob.go", "src//glob.go", ""},
+	},
+	{
+		Pattern: "g*.@(com|org)/@(midbel/toml|midbel/glob)/*md",
+		Inputs:  []string{"github.com/midbel/glob/README.md", "golang.org/midbel/toml/x.md", "github.com/midbel/README.md", "github.com/midbel/glob/a/README.md"},
+	},
+	{
+		Pattern: "**/.*",
+		Inputs:  []string{".git", "a/.git", "a/b", "a/.git/config"},
+	},
+	{
+		Pattern: "**",
+		Inputs:  []string{"", "a", "a/b/c", ".git"},
+	},
+	{
+		Pattern: "a/!(b|c)/d",
+		Inputs:  []string{"a/x/d", "a/b/d", "a/c/d", "a/bc/d", "a/x/y/d"},
+	},
+	{
+		Pattern: "sport/+/player?",
+		Dialect: MQTT,
+		Inputs:  []string{"sport/tennis/player1", "sport//player1", "sport/tennis/player12", "/sport/tennis/player1"},
+	},
+	{
+		Pattern: "sport/#",
+		Dialect: MQTT,
+		Inputs:  []string{"sport", "sport/tennis", "sport/tennis/player1", "$SYS/sport"},
+	},
+	{
+		Pattern: "stock.#.nyse",
+		Dialect: AMQP,
+		Inputs:  []string{"stock.nyse", "stock.usd.ibm.nyse", "stock.usd.ibm", "nyse"},
+	},
+	{
+		Pattern: "*.example.com",
+		Dialect: Hostname,
+		Inputs:  []string{"www.example.com", "WWW.Example.COM.", "example.com", "a.b.example.com"},
+	},
+	{
+		Pattern: `c:\users\*\*.txt`,
+		Dialect: Windows,
+		Inputs:  []string{`C:\Users\midbel\notes.TXT`, `c:/users/midbel/notes.txt`, `d:\users\midbel\notes.txt`, `c:\users\notes.txt`},
+	},
+	{
+		Pattern: "github.com/midbel/...",
+		Dialect: GoPackage,
+		Inputs:  []string{"github.com/midbel", "github.com/midbel/glob", "github.com/midbel/glob/ast", "github.com/other/glob"},
+	},
+	{
+		Pattern: "net/.../http",
+		Dialect: GoPackage,
+		Inputs:  []string{"net/http", "net/x/http", "net/a/b/http", "net/xhttp", "net/http/x"},
+	},
+	{
+		Pattern: "std",
+		Dialect: GoPackage,
+		Inputs:  []string{"fmt", "net/http", "cmd/go", "github.com/midbel/glob"},
+	},
+	{
+		Pattern: "**/a/**/b/**",
+		Dialect: Doublestar,
+		Inputs:  []string{"a/b", "x/a/y/b/z", "b/a", "a/a/a/b"},
+	},
+}
+
+func TestPattern(t *testing.T) {
+	for _, d := range patternCases {
+		p, err := CompilePattern(d.Pattern, WithDialect(d.Dialect))
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", d.Pattern, err)
+			continue
+		}
+		if p.prog == nil {
+			t.Errorf("%q: pattern should be compiled into a program", d.Pattern)
+		}
+		for _, str := range d.Inputs {
+			want := MatchWith(str, p.Matcher, WithDialect(d.Dialect)) == nil
+			if got := p.MatchString(str); got != want {
+				t.Errorf("%q: MatchString(%q) = %t, want %t", d.Pattern, str, got, want)
+			}
+			if got := p.MatchBytes([]byte(str)); got != want {
+				t.Errorf("%q: MatchBytes(%q) = %t, want %t", d.Pattern, str, got, want)
+			}
+		}
+	}
+}
+
+func TestPatternFallback(t *testing.T) {
+	p, err := CompilePattern("src/**/*.go")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	p.Matcher = Seq(p.Matcher, Not(version{}))
+	p.prog = newProgram(p.Matcher)
+	if p.prog != nil {
+		t.Fatalf("%s: custom matchers can not be compiled into a program", p)
+	}
+	if !p.MatchString("src/glob.go/README") {
+		t.Errorf("%s: src/glob.go/README should match", p)
+	}
+	if p.MatchString("src/glob.go/v1.0.0") {
+		t.Errorf("%s: src/glob.go/v1.0.0 should not match", p)
+	}
+}
+
+func TestPatternAllocs(t *testing.T) {
+	for _, d := range patternCases {
+		if d.Dialect == Hostname || d.Dialect == Windows {
+			continue
+		}
+		p, err := CompilePattern(d.Pattern, WithDialect(d.Dialect))
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", d.Pattern, err)
+		}
+		bs := make([][]byte, len(d.Inputs))
+		for i, str := range d.Inputs {
+			bs[i] = []byte(str)
+		}
+		n := testing.AllocsPerRun(10, func() {
+			for i, str := range d.Inputs {
+				p.MatchString(str)
+				p.MatchBytes(bs[i])
+			}
+		})
+		if n != 0 {
+			t.Errorf("%q: %.1f allocations per run", d.Pattern, n)
+		}
+	}
+}
+
+func BenchmarkMatchString(b *testing.B) {
+	p, err := CompilePattern("src/**/@(midbel|golang)/*/*.go")
+	if err != nil {
+		b.Fatalf("unexpected error: %s", err)
+	}
+	str := "src/github.com/midbel/glob/automaton.go"
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if !p.MatchString(str) {
+			b.Fatalf("%s should match %s", str, p)
+		}
+	}
+}
+
+func BenchmarkMatchBytes(b *testing.B) {
+	p, err := CompilePattern("src/**/@(midbel|golang)/*/*.go")
+	if err != nil {
+		b.Fatalf("unexpected error: %s", err)
+	}
+	str := []byte("src/github.com/midbel/glob/automaton.go")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if !p.MatchBytes(str) {
+			b.Fatalf("%s should match %s", str, p)
+		}
+	}
+}
+
+func BenchmarkMatchWith(b *testing.B) {
+	m, err := Compile("src/**/@(midbel|golang)/*/*.go")
+	if err != nil {
+		b.Fatalf("unexpected error: %s", err)
+	}
+	str := "src/github.com/midbel/glob/automaton.go"
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := MatchWith(str, m); err != nil {
+			b.Fatalf("%s should match %s", str, m)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package glob
+
+import (
+	"sort"
+)
+
+// Precedence selects the value returned by PatternMap.Lookup when several
+// patterns match a path.
+type Precedence int
+
+const (
+	// MostSpecific prefers the pattern with the highest Specificity, as
+	// ordered by Score.Compare and SortBySpecificity. The last added pattern
+	// wins the ties.
+	MostSpecific Precedence = iota
+	// LastAdded prefers the pattern inserted last.
+	LastAdded
+)
+
+// PatternMap associates patterns with values of type T.
+//
+// A PatternMap is not safe for concurrent use when Insert is called.
+type PatternMap[T interface{}] struct {
+	prec    Precedence
+	opts    []Option
+	entries []*mapEntry[T]
+	seq     int
+}
+
+type mapEntry[T interface{}] struct {
+	pattern *Pattern
+	score   Score
+	seq     int
+	value   T
+}
+
+// NewPatternMap returns an empty PatternMap. The options are used to compile
+// the patterns given to Insert.
+func NewPatternMap[T interface{}](prec Precedence, opts ...Option) *PatternMap[T] {
+	return &PatternMap[T]{
+		prec: prec,
+		opts: opts,
+	}
+}
+
+// Insert associates value with pattern. Inserting a pattern already present
+// replaces its value and makes it the last added pattern.
+func (p *PatternMap[T]) Insert(pattern string, value T) error {
+	pat, err := CompilePattern(pattern, p.opts...)
+	if err != nil {
+		return err
+	}
+	p.seq++
+	e := mapEntry[T]{
+		pattern: pat,
+		score:   Specificity(pat.Matcher),
+		seq:     p.seq,
+		value:   value,
+	}
+	for i := range p.entries {
+		if p.entries[i].pattern.String() == pat.String() {
+			p.entries = append(p.entries[:i], p.entries[i+1:]...)
+			break
+		}
+	}
+	i := sort.Search(len(p.entries), func(i int) bool {
+		return p.before(&e, p.entries[i])
+	})
+	p.entries = append(p.entries, nil)
+	copy(p.entries[i+1:], p.entries[i:])
+	p.entries[i] = &e
+	return nil
+}
+
+// Lookup gives the value of the pattern matching path that wins according to
+// the precedence of p.
+func (p *PatternMap[T]) Lookup(path string) (T, bool) {
+	for _, e := range p.entries {
+		if e.pattern.MatchString(path) {
+			return e.value, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// LookupAll gives the values of all the patterns matching path, the winner
+// first.
+func (p *PatternMap[T]) LookupAll(path string) []T {
+	var list []T
+	for _, e := range p.entries {
+		if e.pattern.MatchString(path) {
+			list = append(list, e.value)
+		}
+	}
+	return list
+}
+
+// Len gives the number of patterns in p.
+func (p *PatternMap[T]) Len() int {
+	return len(p.entries)
+}
+
+// before reports whether a takes precedence over b.
+func (p *PatternMap[T]) before(a, b *mapEntry[T]) bool {
+	if p.prec == MostSpecific {
+		if c := a.score.Compare(b.score); c != 0 {
+			return c > 0
+		}
+	}
+	return a.seq > b.seq
+}
@@ -0,0 +1,115 @@
+package glob
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPatternMap(t *testing.T) {
+	rules := []struct {
+		Pattern string
+		Owner   string
+	}{
+		{Pattern: "**", Owner: "everyone"},
+		{Pattern: "src/**", Owner: "core"},
+		{Pattern: "src/*/*.go", Owner: "go"},
+		{Pattern: "src/glob/*.go", Owner: "glob"},
+		{Pattern: "src/glob/match.go", Owner: "matcher"},
+		{Pattern: "src/b?ob/*.go", Owner: "single"},
+		{Pattern: "**/*_test.go", Owner: "tests"},
+		{Pattern: "src/glob/*_test.go", Owner: "glob-tests"},
+	}
+	data := []struct {
+		Precedence Precedence
+		Input      string
+		Want       string
+		All        []string
+	}{
+		{Precedence: MostSpecific, Input: "src/glob/match.go", Want: "matcher", All: []string{"matcher", "glob", "go", "core", "everyone"}},
+		{Precedence: MostSpecific, Input: "src/glob/parse.go", Want: "glob"},
+		{Precedence: MostSpecific, Input: "src/blob/parse.go", Want: "single"},
+		{Precedence: MostSpecific, Input: "src/ast/parse.go", Want: "go"},
+		{Precedence: MostSpecific, Input: "src/ast/parse_test.go", Want: "go"},
+		{Precedence: MostSpecific, Input: "src/glob/parse_test.go", Want: "glob-tests"},
+		{Precedence: MostSpecific, Input: "src/a/b/c.go", Want: "core"},
+		{Precedence: MostSpecific, Input: "docs/README.md", Want: "everyone"},
+		{Precedence: LastAdded, Input: "src/glob/match.go", Want: "matcher", All: []string{"matcher", "glob", "go", "core", "everyone"}},
+		{Precedence: LastAdded, Input: "src/glob/match_test.go", Want: "glob-tests", All: []string{"glob-tests", "tests", "glob", "go", "core", "everyone"}},
+		{Precedence: LastAdded, Input: "src/blob/parse.go", Want: "single"},
+		{Precedence: LastAdded, Input: "docs/README.md", Want: "everyone"},
+	}
+	maps := make(map[Precedence]*PatternMap[string])
+	for _, p := range []Precedence{MostSpecific, LastAdded} {
+		pm := NewPatternMap[string](p)
+		for _, r := range rules {
+			if err := pm.Insert(r.Pattern, r.Owner); err != nil {
+				t.Fatalf("%q: unexpected error: %s", r.Pattern, err)
+			}
+		}
+		maps[p] = pm
+	}
+	for _, d := range data {
+		pm := maps[d.Precedence]
+		got, ok := pm.Lookup(d.Input)
+		if !ok || got != d.Want {
+			t.Errorf("%q: want %q, got %q", d.Input, d.Want, got)
+		}
+		if d.All == nil {
+			continue
+		}
+		if all := pm.LookupAll(d.Input); !reflect.DeepEqual(all, d.All) {
+			t.Errorf("%q: want %q, got %q", d.Input, d.All, all)
+		}
+	}
+}
+
+func TestPatternMapReplace(t *testing.T) {
+	pm := NewPatternMap[int](LastAdded, WithDialect(MQTT))
+	for i, p := range []string{"sport/#", "sport/+", "sport/#"} {
+		if err := pm.Insert(p, i); err != nil {
+			t.Fatalf("%q: unexpected error: %s", p, err)
+		}
+	}
+	if pm.Len() != 2 {
+		t.Errorf("map should have 2 patterns, got %d", pm.Len())
+	}
+	if got, _ := pm.Lookup("sport/tennis"); got != 2 {
+		t.Errorf("sport/tennis: want 2, got %d", got)
+	}
+	if _, ok := pm.Lookup("news"); ok {
+		t.Errorf("news: unexpected match")
+	}
+	if err := pm.Insert("sport/#/x", 3); err == nil {
+		t.Errorf("sport/#/x: expected error")
+	}
+	if err := NewPatternMap[int](MostSpecific).Insert("//", 0); err == nil {
+		t.Errorf("//: expected error")
+	}
+}
+
+// TestPatternMapSpecificity checks that MostSpecific follows the order of
+// SortBySpecificity.
+func TestPatternMapSpecificity(t *testing.T) {
+	data := []struct {
+		Patterns []string
+		Input    string
+	}{
+		{Patterns: []string{"a/**/c", "*/b/c"}, Input: "a/b/c"},
+		{Patterns: []string{"a/**/x.go", "*/b/x.go"}, Input: "a/b/x.go"},
+		{Patterns: []string{"src/*.go", "src/?ain.go", "*/main.go"}, Input: "src/main.go"},
+		{Patterns: []string{"@(a|b)/c", "?/c", "a/*"}, Input: "a/c"},
+	}
+	for _, d := range data {
+		pm := NewPatternMap[string](MostSpecific)
+		for _, p := range d.Patterns {
+			if err := pm.Insert(p, p); err != nil {
+				t.Fatalf("%q: unexpected error: %s", p, err)
+			}
+		}
+		sorted := append([]string(nil), d.Patterns...)
+		SortBySpecificity(sorted)
+		if got := pm.LookupAll(d.Input); !reflect.DeepEqual(got, sorted) {
+			t.Errorf("%q: order mismatched! want %q, got %q", d.Input, sorted, got)
+		}
+	}
+}
@@ -0,0 +1,438 @@
+package glob
+
+import (
+	"slices"
+	"sort"
+	"sync"
+)
+
+// PatternSet matches a path against many patterns at once and reports which
+// of them match.
+//
+// The patterns are merged into one automaton whose transitions consume whole
+// segments. The leading literal segments of the patterns are shared in a
+// prefix tree and the literals required by the other segments are searched
+// with a single Aho-Corasick pass over each segment, so that only the
+// automata of the segments that can match are run. The segments following a
+// leading ** are tried on every segment of the path but only when their
+// literal has been found.
+//
+// A PatternSet is safe for concurrent use.
+type PatternSet struct {
+	cfg  *config
+	size int
+
+	prog    program
+	closure [][]int32
+	// anchors gives for each node of prog the literal that a segment must
+	// contain to be matched, -1 if none
+	anchors []int32
+	search  *acMachine
+	root    *trieNode
+
+	// nodes following a leading **: the ones without literal, the ones
+	// indexed by their literal and the patterns accepting any path
+	floating []int32
+	anchored [][]int32
+	finals   []int
+
+	// matchers that can not be part of prog, with their index
+	others  []Matcher
+	indices []int
+
+	pool sync.Pool
+}
+
+// NewPatternSet compiles patterns into a PatternSet. The indices reported
+// by Match are the positions of the patterns in the given slice.
+func NewPatternSet(patterns []string, opts ...Option) (*PatternSet, error) {
+	s := PatternSet{
+		cfg:  configure(opts),
+		size: len(patterns),
+		root: &trieNode{},
+	}
+	var floats []int32
+	for i, pat := range patterns {
+		m, err := s.cfg.dialect.compile(pat, s.cfg)
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := s.add(m, i); ok {
+			floats = append(floats, start)
+		}
+	}
+	s.closure = make([][]int32, len(s.prog.nodes))
+	for i := range s.prog.nodes {
+		s.closure[i] = s.prog.closeAll(i)
+	}
+	var lits []string
+	s.anchors, lits = anchorsOf(s.prog.nodes)
+	s.search = newACMachine(lits)
+	s.anchored = make([][]int32, len(lits))
+	for _, i := range floats {
+		for _, j := range s.closure[i] {
+			switch n := s.prog.nodes[j]; {
+			case n.kind == nodeFinal:
+				s.finals = append(s.finals, n.next)
+			case n.kind == nodeSplit:
+			case s.anchors[j] >= 0:
+				a := s.anchors[j]
+				s.anchored[a] = append(s.anchored[a], j)
+			default:
+				s.floating = append(s.floating, j)
+			}
+		}
+	}
+	s.pool.New = func() interface{} {
+		return newSetState(len(s.prog.nodes), len(lits))
+	}
+	return &s, nil
+}
+
+// Len gives the number of patterns of s.
+func (s *PatternSet) Len() int {
+	return s.size
+}
+
+// add inserts the matcher of the pattern at index i. Its leading literal
+// segments go in the prefix tree, the remaining ones in the automaton. It
+// reports true with the first node of the pattern when it starts with **.
+func (s *PatternSet) add(m Matcher, i int) (int32, bool) {
+	var (
+		t    = s.root
+		rest = m
+	)
+	for rest != nil {
+		e, ok := rest.(*element)
+		if !ok {
+			break
+		}
+		lit, ok := literalSegment(e.head)
+		if !ok {
+			break
+		}
+		t = t.child(lit)
+		rest = e.next
+	}
+	var float bool
+	if e, ok := rest.(*element); ok && t == s.root && isGlobstar(e.head) {
+		rest, float = e.next, true
+	}
+	final := len(s.prog.nodes)
+	s.prog.nodes = append(s.prog.nodes, node{kind: nodeFinal, next: i})
+	start := final
+	if rest != nil {
+		var ok bool
+		if start, ok = s.prog.chain(rest, final); !ok {
+			s.prog.nodes = s.prog.nodes[:final]
+			s.others = append(s.others, m)
+			s.indices = append(s.indices, i)
+			return 0, false
+		}
+	}
+	if float {
+		return int32(start), true
+	}
+	t.starts = append(t.starts, int32(start))
+	return 0, false
+}
+
+// Match gives the indices, in increasing order, of the patterns that match
+// str.
+func (s *PatternSet) Match(str string) []int {
+	var (
+		parts = s.cfg.dialect.split(str, s.cfg)
+		state = s.pool.Get().(*setState)
+		cur   = &state.cur
+		next  = &state.next
+		t     = s.root
+	)
+	defer s.pool.Put(state)
+
+	cur.clear()
+	for _, i := range t.starts {
+		s.activate(cur, i)
+	}
+	for _, seg := range parts {
+		next.clear()
+		if state.gen++; state.gen == 0 {
+			clear(state.found)
+			state.gen++
+		}
+		state.hits = s.search.find(seg, state.found, state.gen, state.hits[:0])
+		for _, i := range cur.dense {
+			s.step(i, seg, next, state)
+		}
+		for _, i := range s.floating {
+			s.step(i, seg, next, state)
+		}
+		for _, a := range state.hits {
+			for _, i := range s.anchored[a] {
+				s.step(i, seg, next, state)
+			}
+		}
+		if t != nil {
+			if t = t.children[seg]; t != nil {
+				for _, i := range t.starts {
+					s.activate(next, i)
+				}
+			}
+		}
+		cur, next = next, cur
+	}
+	list := append([]int(nil), s.finals...)
+	for _, i := range cur.dense {
+		if n := &s.prog.nodes[i]; n.kind == nodeFinal {
+			list = append(list, n.next)
+		}
+	}
+	for i, m := range s.others {
+		if matchSegments(m, parts) == nil {
+			list = append(list, s.indices[i])
+		}
+	}
+	sort.Ints(list)
+	return slices.Compact(list)
+}
+
+// step activates the nodes following i if it matches seg.
+func (s *PatternSet) step(i int32, seg string, next *sparseSet, state *setState) {
+	n := &s.prog.nodes[i]
+	switch n.kind {
+	case nodeGlobstar:
+		s.activate(next, i)
+	case nodeSegment:
+		if a := s.anchors[i]; a >= 0 && state.found[a] != state.gen {
+			return
+		}
+		if segmentMatch(n.m, seg) {
+			s.activate(next, int32(n.next))
+		}
+	}
+}
+
+func (s *PatternSet) activate(set *sparseSet, i int32) {
+	for _, j := range s.closure[i] {
+		set.add(j)
+	}
+}
+
+// literalSegment gives the only segment matched by m if any.
+func literalSegment(m Matcher) (string, bool) {
+	a, ok := m.(*automaton)
+	if !ok || !a.lits.exact || !a.match(a.lits.prefix) {
+		return "", false
+	}
+	return a.lits.prefix, true
+}
+
+// anchorsOf picks the longest literal required by each segment node and
+// gives the list of the distinct literals.
+func anchorsOf(nodes []node) ([]int32, []string) {
+	var (
+		anchors = make([]int32, len(nodes))
+		lits    []string
+		seen    = make(map[string]int32)
+	)
+	for i, n := range nodes {
+		anchors[i] = -1
+		a, ok := n.m.(*automaton)
+		if !ok || n.kind != nodeSegment {
+			continue
+		}
+		str := a.lits.prefix
+		for _, s := range append([]string{a.lits.suffix}, a.lits.inner...) {
+			if len(s) > len(str) {
+				str = s
+			}
+		}
+		if str == "" {
+			continue
+		}
+		x, ok := seen[str]
+		if !ok {
+			x = int32(len(lits))
+			seen[str] = x
+			lits = append(lits, str)
+		}
+		anchors[i] = x
+	}
+	return anchors, lits
+}
+
+// closeAll is like close for the programs of a PatternSet that are too large
+// for a stateSet.
+func (p *program) closeAll(i int) []int32 {
+	var (
+		list  []int32
+		seen  = make(map[int]bool)
+		visit func(int)
+	)
+	visit = func(i int) {
+		if seen[i] {
+			return
+		}
+		seen[i] = true
+		list = append(list, int32(i))
+		switch n := p.nodes[i]; n.kind {
+		case nodeGlobstar:
+			visit(n.next)
+		case nodeSplit:
+			for _, i := range n.alts {
+				visit(i)
+			}
+		}
+	}
+	visit(i)
+	return list
+}
+
+type trieNode struct {
+	children map[string]*trieNode
+	// starts are the nodes of the program reached after the literal
+	// segments leading to this node
+	starts []int32
+}
+
+func (t *trieNode) child(seg string) *trieNode {
+	if t.children == nil {
+		t.children = make(map[string]*trieNode)
+	}
+	c, ok := t.children[seg]
+	if !ok {
+		c = &trieNode{}
+		t.children[seg] = c
+	}
+	return c
+}
+
+// setState holds the buffers used by a call to Match.
+type setState struct {
+	cur   sparseSet
+	next  sparseSet
+	found []uint32
+	gen   uint32
+	hits  []int32
+}
+
+func newSetState(nodes, lits int) *setState {
+	return &setState{
+		cur:   newSparseSet(nodes),
+		next:  newSparseSet(nodes),
+		found: make([]uint32, lits),
+	}
+}
+
+// sparseSet is a set of integers that is cleared in constant time.
+type sparseSet struct {
+	dense  []int32
+	sparse []int32
+}
+
+func newSparseSet(n int) sparseSet {
+	return sparseSet{
+		dense:  make([]int32, 0, n),
+		sparse: make([]int32, n),
+	}
+}
+
+func (s *sparseSet) add(i int32) {
+	if s.has(i) {
+		return
+	}
+	s.sparse[i] = int32(len(s.dense))
+	s.dense = append(s.dense, i)
+}
+
+func (s *sparseSet) has(i int32) bool {
+	j := s.sparse[i]
+	return int(j) < len(s.dense) && s.dense[j] == i
+}
+
+func (s *sparseSet) clear() {
+	s.dense = s.dense[:0]
+}
+
+// acMachine is an Aho-Corasick automaton finding all the occurrences of a
+// list of literals in one pass over a string.
+type acMachine struct {
+	states []acState
+}
+
+type acState struct {
+	next map[byte]int32
+	fail int32
+	// out gives the literals ending at this state
+	out []int32
+}
+
+func newACMachine(lits []string) *acMachine {
+	ac := acMachine{
+		states: []acState{{}},
+	}
+	for i, str := range lits {
+		var s int32
+		for j := 0; j < len(str); j++ {
+			x, ok := ac.states[s].next[str[j]]
+			if !ok {
+				x = int32(len(ac.states))
+				ac.states = append(ac.states, acState{})
+				if ac.states[s].next == nil {
+					ac.states[s].next = make(map[byte]int32)
+				}
+				ac.states[s].next[str[j]] = x
+			}
+			s = x
+		}
+		ac.states[s].out = append(ac.states[s].out, int32(i))
+	}
+	queue := make([]int32, 0, len(ac.states))
+	for _, x := range ac.states[0].next {
+		queue = append(queue, x)
+	}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		for c, x := range ac.states[s].next {
+			f := ac.states[s].fail
+			for {
+				if y, ok := ac.states[f].next[c]; ok {
+					ac.states[x].fail = y
+					break
+				}
+				if f == 0 {
+					break
+				}
+				f = ac.states[f].fail
+			}
+			fail := ac.states[x].fail
+			ac.states[x].out = append(ac.states[x].out, ac.states[fail].out...)
+			queue = append(queue, x)
+		}
+	}
+	return &ac
+}
+
+// find marks with gen the literals found in str and appends them to hits.
+func (ac *acMachine) find(str string, found []uint32, gen uint32, hits []int32) []int32 {
+	var s int32
+	for i := 0; i < len(str); i++ {
+		for {
+			if x, ok := ac.states[s].next[str[i]]; ok {
+				s = x
+				break
+			}
+			if s == 0 {
+				break
+			}
+			s = ac.states[s].fail
+		}
+		for _, x := range ac.states[s].out {
+			if found[x] != gen {
+				found[x] = gen
+				hits = append(hits, x)
+			}
+		}
+	}
+	return hits
+}
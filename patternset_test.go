@@ -0,0 +1,128 @@
+package glob
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestPatternSet(t *testing.T) {
+	data := []struct {
+		Dialect  Dialect
+		Patterns []string
+		Inputs   []string
+	}{
+		{
+			Patterns: []string{
+				"src/**/*.go",
+				"src/glob/*.go",
+				"src/glob/match.go",
+				"src/glob",
+				"**/*_test.go",
+				"**/testdata/**",
+				"**/glob/**/*.go",
+				"**/?(ast)",
+				"docs/*.md",
+				"*.@(md|txt)",
+				"src/!(vendor)/**",
+				"src/@(glob/ast|glob/cmd)/*.go",
+				"x@(a/b|c)",
+				"**",
+			},
+			Inputs: []string{
+				"src/glob/match.go",
+				"src/glob/match_test.go",
+				"src/glob",
+				"src/vendor/lib.go",
+				"src/glob/ast/ast.go",
+				"src/glob/testdata/file.txt",
+				"docs/README.md",
+				"README.md",
+				"xa/b",
+				"xc",
+				"",
+			},
+		},
+		{
+			Dialect:  Hostname,
+			Patterns: []string{"*.example.com", "www.example.com", "example.com", "*.golang.org"},
+			Inputs:   []string{"WWW.example.com", "example.com", "a.b.example.com", "golang.org"},
+		},
+		{
+			Dialect:  MQTT,
+			Patterns: []string{"sport/#", "sport/+/player1", "sport/tennis/+", "#", "+/+"},
+			Inputs:   []string{"sport", "sport/tennis/player1", "sport/tennis", "$SYS/info"},
+		},
+	}
+	for _, d := range data {
+		set, err := NewPatternSet(d.Patterns, WithDialect(d.Dialect))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", d.Dialect, err)
+			continue
+		}
+		if set.Len() != len(d.Patterns) {
+			t.Errorf("%s: set should have %d patterns, got %d", d.Dialect, len(d.Patterns), set.Len())
+		}
+		for _, str := range d.Inputs {
+			var want []int
+			for i, pat := range d.Patterns {
+				if Match(str, pat, WithDialect(d.Dialect)) == nil {
+					want = append(want, i)
+				}
+			}
+			if got := set.Match(str); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: %q matched by %v, want %v", d.Dialect, str, got, want)
+			}
+		}
+	}
+}
+
+func TestPatternSetError(t *testing.T) {
+	_, err := NewPatternSet([]string{"src/**", "[a-"})
+	if _, ok := err.(*PatternError); !ok {
+		t.Errorf("expected pattern error, got %v", err)
+	}
+}
+
+func TestACMachine(t *testing.T) {
+	var (
+		lits  = []string{"he", "she", "his", "hers", "s"}
+		ac    = newACMachine(lits)
+		found = make([]uint32, len(lits))
+	)
+	hits := ac.find("ushers", found, 1, nil)
+	if want := []int32{4, 1, 0, 3}; !reflect.DeepEqual(hits, want) {
+		t.Errorf("literals found mismatched: want %v, got %v", want, hits)
+	}
+	if want := []uint32{1, 1, 0, 1, 1}; !reflect.DeepEqual(found, want) {
+		t.Errorf("literals marked mismatched: want %v, got %v", want, found)
+	}
+}
+
+func BenchmarkPatternSet(b *testing.B) {
+	var patterns []string
+	for i := 0; i < 5000; i++ {
+		switch i % 4 {
+		case 0:
+			patterns = append(patterns, fmt.Sprintf("services/svc%d/**", i))
+		case 1:
+			patterns = append(patterns, fmt.Sprintf("**/owner%d/*.go", i))
+		case 2:
+			patterns = append(patterns, fmt.Sprintf("lib/*/module%d_*.go", i))
+		default:
+			patterns = append(patterns, fmt.Sprintf("docs/team%d/*.@(md|txt)", i))
+		}
+	}
+	set, err := NewPatternSet(patterns)
+	if err != nil {
+		b.Fatalf("unexpected error: %s", err)
+	}
+	str := "services/svc400/internal/owner401/handler.go"
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if got := set.Match(str); len(got) != 2 {
+			b.Fatalf("%s: unexpected matches %v", str, got)
+		}
+	}
+}
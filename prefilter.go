@@ -0,0 +1,169 @@
+package glob
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// literals are the strings that every segment matched by a matcher contains.
+// They are checked before running the automaton so that most of the segments
+// that can not match are rejected without walking them.
+type literals struct {
+	// prefix and suffix begin and end the segments
+	prefix string
+	suffix string
+	// inner are found anywhere in the segments
+	inner []string
+	// exact is set when the segments are always equal to prefix
+	exact bool
+}
+
+// reject reports whether str can not be matched.
+func (l *literals) reject(str string) bool {
+	if l.exact {
+		return str != l.prefix
+	}
+	if !strings.HasPrefix(str, l.prefix) || !strings.HasSuffix(str, l.suffix) {
+		return true
+	}
+	for _, s := range l.inner {
+		if strings.Index(str, s) < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// literalsOf extracts the literals of the segments matched by m.
+func literalsOf(m Matcher) literals {
+	switch m := m.(type) {
+	case *simple:
+		return patternLiterals(m.pattern)
+	case *automaton:
+		return m.lits
+	case *multiple:
+		l := exactly("")
+		for _, m := range m.ms {
+			l = l.cat(literalsOf(m))
+		}
+		return l
+	case *group:
+		ls := make([]literals, 0, len(m.ms))
+		for _, m := range m.ms {
+			ls = append(ls, literalsOf(m))
+		}
+		return alternate(ls)
+	case *any:
+		if m.min == 0 {
+			break
+		}
+		// the segments start and end with a repetition of the inner matcher
+		l := literalsOf(m.inner)
+		l.exact = l.exact && m.max == 1
+		return l
+	case *visible:
+		return literalsOf(m.inner)
+	case *element:
+		if m.next == nil {
+			return literalsOf(m.head)
+		}
+	}
+	return literals{}
+}
+
+func exactly(str string) literals {
+	return literals{
+		prefix: str,
+		suffix: str,
+		exact:  true,
+	}
+}
+
+// patternLiterals splits the pattern of a simple matcher on its wildcards.
+func patternLiterals(pat string) literals {
+	var (
+		l   = exactly("")
+		buf strings.Builder
+	)
+	flush := func() {
+		l = l.cat(exactly(buf.String()))
+		buf.Reset()
+	}
+	for i := 0; i < len(pat); {
+		k, n := utf8.DecodeRuneInString(pat[i:])
+		switch {
+		case k == star || k == mark:
+			flush()
+			l = l.cat(literals{})
+		case k == lsquare && classSize(pat[i:]) > 0:
+			n = classSize(pat[i:])
+			flush()
+			l = l.cat(literals{})
+		case k == backslash && i+n < len(pat):
+			k, z := utf8.DecodeRuneInString(pat[i+n:])
+			n += z
+			buf.WriteRune(k)
+		default:
+			buf.WriteRune(k)
+		}
+		i += n
+	}
+	flush()
+	return l
+}
+
+// cat gives the literals of the concatenation of the segments matched by l
+// and o.
+func (l literals) cat(o literals) literals {
+	switch {
+	case l.exact && o.exact:
+		return exactly(l.prefix + o.prefix)
+	case l.exact:
+		o.prefix = l.prefix + o.prefix
+		return o
+	case o.exact:
+		l.suffix += o.suffix
+		return l
+	}
+	x := literals{
+		prefix: l.prefix,
+		suffix: o.suffix,
+	}
+	x.inner = append(x.inner, l.inner...)
+	if s := l.suffix + o.prefix; s != "" {
+		x.inner = append(x.inner, s)
+	}
+	x.inner = append(x.inner, o.inner...)
+	return x
+}
+
+// alternate gives the literals shared by all the alternatives of ls.
+func alternate(ls []literals) literals {
+	if len(ls) == 0 {
+		return literals{}
+	}
+	x := ls[0]
+	x.inner = nil
+	for _, l := range ls[1:] {
+		x.exact = x.exact && l.exact && x.prefix == l.prefix
+		x.prefix = commonPrefix(x.prefix, l.prefix)
+		x.suffix = commonSuffix(x.suffix, l.suffix)
+	}
+	return x
+}
+
+func commonPrefix(a, b string) string {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return a[:i]
+}
+
+func commonSuffix(a, b string) string {
+	i := 0
+	for i < len(a) && i < len(b) && a[len(a)-1-i] == b[len(b)-1-i] {
+		i++
+	}
+	return a[len(a)-i:]
+}
@@ -0,0 +1,60 @@
+package glob
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLiterals(t *testing.T) {
+	data := []struct {
+		Pattern string
+		Prefix  string
+		Suffix  string
+		Inner   []string
+		Exact   bool
+	}{
+		{Pattern: "foo", Prefix: "foo", Suffix: "foo", Exact: true},
+		{Pattern: `a\*b`, Prefix: "a*b", Suffix: "a*b", Exact: true},
+		{Pattern: "*.parquet", Suffix: ".parquet"},
+		{Pattern: "report-*-final.csv", Prefix: "report-", Suffix: "-final.csv"},
+		{Pattern: "a*bc*d?e", Prefix: "a", Suffix: "e", Inner: []string{"bc", "d"}},
+		{Pattern: "[abc]x*", Inner: []string{"x"}},
+		{Pattern: "*.@(tar.gz|gz)", Suffix: "gz", Inner: []string{"."}},
+		{Pattern: "@(foo|bar)", Exact: false},
+		{Pattern: "@(foo|foo)", Prefix: "foo", Suffix: "foo", Exact: true},
+		{Pattern: "@(src|srv)/*", Prefix: "sr"},
+		{Pattern: "x+(ab)y", Prefix: "xab", Suffix: "aby"},
+		{Pattern: "x*(ab)y", Prefix: "x", Suffix: "y"},
+		{Pattern: "!(*.go)", Exact: false},
+		{Pattern: "*.!(go)", Inner: []string{"."}},
+	}
+	for _, d := range data {
+		m, err := Extended.build(d.Pattern, configure(nil))
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", d.Pattern, err)
+			continue
+		}
+		e, _ := m.(*element)
+		l := literalsOf(e.head)
+		if l.prefix != d.Prefix || l.suffix != d.Suffix || l.exact != d.Exact {
+			t.Errorf("%q: literals mismatched: want %q, %q (exact: %t), got %q, %q (exact: %t)", d.Pattern, d.Prefix, d.Suffix, d.Exact, l.prefix, l.suffix, l.exact)
+		}
+		if !reflect.DeepEqual(l.inner, d.Inner) {
+			t.Errorf("%q: inner literals mismatched: want %q, got %q", d.Pattern, d.Inner, l.inner)
+		}
+	}
+}
+
+func BenchmarkReject(b *testing.B) {
+	p, err := CompilePattern("**/report-*-final.csv")
+	if err != nil {
+		b.Fatalf("unexpected error: %s", err)
+	}
+	str := "data/2024/reports/report-q1-2024-draft-version-with-a-long-name.txt"
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if p.MatchString(str) {
+			b.Fatalf("%s should not match %s", str, p)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package glob
+
+import (
+	"strings"
+)
+
+// parameters of the bootstring algorithm for punycode (RFC 3492 section 5)
+const (
+	punyBase    = 36
+	punyTmin    = 1
+	punyTmax    = 26
+	punySkew    = 38
+	punyDamp    = 700
+	punyInitial = 128
+	punyBias    = 72
+)
+
+// punycode encodes str following the algorithm of RFC 3492 section 6.3.
+func punycode(str string) string {
+	var (
+		buf   strings.Builder
+		runes = []rune(str)
+		n     = punyInitial
+		bias  = punyBias
+		delta int
+		basic int
+	)
+	for _, r := range runes {
+		if r < punyInitial {
+			buf.WriteRune(r)
+			basic++
+		}
+	}
+	if basic > 0 {
+		buf.WriteRune(dash)
+	}
+	for h := basic; h < len(runes); {
+		m := int(^uint32(0) >> 1)
+		for _, r := range runes {
+			if int(r) >= n && int(r) < m {
+				m = int(r)
+			}
+		}
+		delta += (m - n) * (h + 1)
+		n = m
+		for _, r := range runes {
+			if int(r) < n {
+				delta++
+			}
+			if int(r) != n {
+				continue
+			}
+			q := delta
+			for k := punyBase; ; k += punyBase {
+				t := k - bias
+				if t < punyTmin {
+					t = punyTmin
+				} else if t > punyTmax {
+					t = punyTmax
+				}
+				if q < t {
+					break
+				}
+				buf.WriteByte(punyDigit(t + (q-t)%(punyBase-t)))
+				q = (q - t) / (punyBase - t)
+			}
+			buf.WriteByte(punyDigit(q))
+			bias = punyAdapt(delta, h+1, h == basic)
+			delta = 0
+			h++
+		}
+		delta++
+		n++
+	}
+	return buf.String()
+}
+
+func punyAdapt(delta, points int, first bool) int {
+	if first {
+		delta /= punyDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / points
+	var k int
+	for delta > ((punyBase-punyTmin)*punyTmax)/2 {
+		delta /= punyBase - punyTmin
+		k += punyBase
+	}
+	return k + (punyBase-punyTmin+1)*delta/(delta+punySkew)
+}
+
+func punyDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
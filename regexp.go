@@ -0,0 +1,455 @@
+package glob
+
+import (
+	"fmt"
+	"regexp"
+	resyntax "regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxRegexpStates = 64
+	maxRegexpLength = 1 << 14
+)
+
+// ToRegexp translates pattern to an anchored regular expression accepted by
+// the regexp package and the other RE2 engines. The wildcards never match a
+// separator: * becomes [^/]*, ** a repetition of whole segments and the
+// extended patterns groups with quantifiers. The negations, !(...) and the
+// rules on leading dots, are translated from the automaton of their segment.
+// An error is returned when the resulting expression would be too large.
+//
+// The expression matches the paths as given: unlike Match, the separators
+// around the path are not trimmed and the paths of the Windows and Hostname
+// dialects are not normalized, their expressions being only case insensitive.
+func ToRegexp(pattern string, opts ...Option) (string, error) {
+	cfg := configure(opts)
+	m, err := cfg.dialect.build(pattern, cfg)
+	if err != nil {
+		return "", err
+	}
+	var (
+		t      = newTranslator(cfg.dialect)
+		prefix string
+		str    string
+	)
+	if cfg.dialect == Windows {
+		prefix, m = windowsRoot(m)
+	}
+	if m != nil {
+		if str, err = t.path(optimize(m)); err != nil {
+			return "", err
+		}
+	}
+	str = prefix + str
+	if len(str) > maxRegexpLength {
+		return "", fmt.Errorf("regexp: expression of %q too large", pattern)
+	}
+	str = "^" + str + "$"
+	if cfg.dialect == Windows || cfg.dialect == Hostname {
+		str = "(?i)" + str
+	}
+	return str, nil
+}
+
+// translator converts the tree of a matcher to a regular expression.
+type translator struct {
+	b *builder
+	// seps are the characters separating the segments and sep the
+	// expression matching them
+	seps []runeRange
+	sep  string
+	// one and seg match one character and all the characters of a segment
+	one string
+	seg string
+	// segment is the expression of the strings without separators
+	segment *expr
+}
+
+func newTranslator(d Dialect) *translator {
+	t := translator{
+		b:    newBuilder(),
+		seps: []runeRange{{lo: slash, hi: slash}},
+	}
+	switch d {
+	case AMQP, Hostname:
+		t.seps = []runeRange{{lo: '.', hi: '.'}}
+	case Windows:
+		t.seps = normalizeRanges([]runeRange{{lo: slash, hi: slash}, {lo: backslash, hi: backslash}})
+	}
+	t.sep = t.class(t.seps)
+	if d == Windows {
+		t.sep += "+"
+	}
+	t.one = t.class(negateRanges(t.seps))
+	t.seg = t.one + "*"
+	sep := t.b.set(t.seps)
+	t.segment = t.b.not(t.b.cat(t.b.all, t.b.cat(sep, t.b.all)))
+	return &t
+}
+
+// windowsRoot splits the prefix of a Windows path from the following
+// segments. The prefix is translated with the separators following it, the
+// segments being then relative to it.
+func windowsRoot(m Matcher) (string, Matcher) {
+	var (
+		head = m
+		next Matcher
+	)
+	if e, ok := m.(*element); ok {
+		head, next = e.head, e.next
+	}
+	s, ok := head.(*simple)
+	if !ok {
+		return "", m
+	}
+	const (
+		sep  = `[/\\]`
+		long = `[/\\]{2}[?.][/\\]`
+	)
+	switch pat := s.pattern; {
+	case pat == quote(uncPrefix):
+		return "(?:" + long + "unc" + sep + "|" + sep + "{2})" + sep + "*", next
+	case pat == quote(rootPrefix):
+		return sep, next
+	case len(pat) == 2 && isDrive(pat[0]) && pat[1] == ':':
+		return "(?:" + long + ")?" + pat + sep + "*", next
+	default:
+		return "", m
+	}
+}
+
+// path translates a chain of segments joined by separators.
+func (t *translator) path(m Matcher) (string, error) {
+	var (
+		buf   strings.Builder
+		ms    = appendChain(nil, m)
+		first = true
+		skip  bool // no separator before the next segment
+	)
+	for i, m := range ms {
+		if isGlobstar(m) {
+			if i > 0 && isGlobstar(ms[i-1]) {
+				continue
+			}
+			switch last := i == len(ms)-1; {
+			case first && last:
+				buf.WriteString(t.seg + "(?:" + t.sep + t.seg + ")*")
+			case first:
+				buf.WriteString("(?:" + t.seg + t.sep + ")*")
+				skip = true
+			default:
+				buf.WriteString("(?:" + t.sep + t.seg + ")*")
+			}
+			first = false
+			continue
+		}
+		str, err := t.head(m)
+		if err != nil {
+			return "", err
+		}
+		if !first && !skip {
+			buf.WriteString(t.sep)
+		}
+		buf.WriteString(str)
+		first, skip = false, false
+	}
+	return buf.String(), nil
+}
+
+// head translates a segment or a group of alternatives spanning multiple
+// segments.
+func (t *translator) head(m Matcher) (string, error) {
+	g, ok := m.(*group)
+	if !ok || !spanSegments(g) {
+		return t.segmentOf(m)
+	}
+	var list []string
+	for _, m := range g.ms {
+		if e, ok := m.(*element); ok && isGlobstar(e.head) && e.next == nil {
+			return "", fmt.Errorf("regexp: %s can not be translated", g)
+		}
+		str, err := t.path(m)
+		if err != nil {
+			return "", err
+		}
+		list = append(list, str)
+	}
+	return "(?:" + strings.Join(list, "|") + ")", nil
+}
+
+func spanSegments(g *group) bool {
+	for _, m := range g.ms {
+		if e, ok := m.(*element); ok && (e.next != nil || isGlobstar(e.head)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *translator) segmentOf(m Matcher) (string, error) {
+	switch m := m.(type) {
+	case *simple:
+		return t.pattern(m.pattern), nil
+	case *automaton:
+		return t.segmentOf(m.src)
+	case *multiple:
+		var buf strings.Builder
+		for _, m := range m.ms {
+			str, err := t.segmentOf(m)
+			if err != nil {
+				return "", err
+			}
+			buf.WriteString(str)
+		}
+		return buf.String(), nil
+	case *group:
+		var list []string
+		for _, m := range m.ms {
+			str, err := t.segmentOf(m)
+			if err != nil {
+				return "", err
+			}
+			list = append(list, str)
+		}
+		return "(?:" + strings.Join(list, "|") + ")", nil
+	case *any:
+		str, err := t.segmentOf(m.inner)
+		if err != nil {
+			return "", err
+		}
+		if _, ok := m.inner.(*group); !ok {
+			str = "(?:" + str + ")"
+		}
+		switch {
+		case m.min == 0 && m.max == 0:
+			return str + "*", nil
+		case m.min == 1 && m.max == 0:
+			return str + "+", nil
+		case m.min == 0 && m.max == 1:
+			return str + "?", nil
+		case m.max == 0:
+			return str + "{" + strconv.Itoa(m.min) + ",}", nil
+		default:
+			return str + "{" + strconv.Itoa(m.min) + "," + strconv.Itoa(m.max) + "}", nil
+		}
+	case *visible:
+		// the rule is only enforced when the inner matcher accepts a dot
+		x, ok := t.b.fromMatcher(m.inner)
+		if ok && t.b.derive(x, '.') == t.b.none {
+			return t.segmentOf(m.inner)
+		}
+		return t.automaton(m)
+	case *not:
+		return t.automaton(m)
+	case *element:
+		if m.next == nil && !isGlobstar(m.head) {
+			return t.segmentOf(m.head)
+		}
+	}
+	return "", fmt.Errorf("regexp: %s can not be translated", m)
+}
+
+// pattern translates the pattern of a simple matcher.
+func (t *translator) pattern(pat string) string {
+	var buf strings.Builder
+	for i := 0; i < len(pat); {
+		k, n := utf8.DecodeRuneInString(pat[i:])
+		switch {
+		case k == star:
+			buf.WriteString(t.seg)
+		case k == mark:
+			buf.WriteString(t.one)
+		case k == lsquare && classSize(pat[i:]) > 0:
+			n = classSize(pat[i:])
+			buf.WriteString(t.class(classRanges(pat[i+1 : i+n-1])))
+		case k == backslash && i+n < len(pat):
+			k, z := utf8.DecodeRuneInString(pat[i+n:])
+			n += z
+			buf.WriteString(regexp.QuoteMeta(string(k)))
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(k)))
+		}
+		i += n
+	}
+	return buf.String()
+}
+
+// class gives the expression of one of the characters of rs that are not
+// separators.
+func (t *translator) class(rs []runeRange) string {
+	if t.one != "" {
+		rs = intersectRanges(rs, negateRanges(t.seps))
+	}
+	if len(rs) == 1 && rs[0].lo == rs[0].hi {
+		return regexp.QuoteMeta(string(rs[0].lo))
+	}
+	var (
+		buf strings.Builder
+		neg = negateRanges(rs)
+	)
+	buf.WriteRune(lsquare)
+	if len(neg) < len(rs) || len(rs) == 0 {
+		buf.WriteRune(caret)
+		rs = neg
+	}
+	for _, r := range rs {
+		buf.WriteString(quoteRegexpRune(r.lo))
+		if r.hi > r.lo {
+			if r.hi > r.lo+1 {
+				buf.WriteRune(dash)
+			}
+			buf.WriteString(quoteRegexpRune(r.hi))
+		}
+	}
+	buf.WriteRune(rsquare)
+	return buf.String()
+}
+
+func intersectRanges(a, b []runeRange) []runeRange {
+	var list []runeRange
+	for _, x := range a {
+		for _, y := range b {
+			lo, hi := max(x.lo, y.lo), min(x.hi, y.hi)
+			if lo <= hi {
+				list = append(list, runeRange{lo: lo, hi: hi})
+			}
+		}
+	}
+	return normalizeRanges(list)
+}
+
+func quoteRegexpRune(k rune) string {
+	switch {
+	case k == backslash || k == lsquare || k == rsquare || k == caret || k == dash:
+		return string([]rune{backslash, k})
+	case k < utf8.RuneSelf && unicode.IsPrint(k):
+		return string(k)
+	default:
+		return fmt.Sprintf(`\x{%x}`, k)
+	}
+}
+
+// automaton translates m by eliminating the states of the automaton of the
+// strings of one segment it matches.
+func (t *translator) automaton(m Matcher) (string, error) {
+	x, ok := t.b.fromMatcher(m)
+	if !ok {
+		return "", fmt.Errorf("regexp: %s can not be translated", m)
+	}
+	x = t.b.and(x, t.segment)
+
+	var (
+		states = map[*expr]int{x: 0}
+		list   = []*expr{x}
+		edges  = make(map[[2]int][]runeRange)
+	)
+	for i := 0; i < len(list); i++ {
+		bounds := t.b.classes(list[i])
+		for j, lo := range bounds {
+			hi := rune(utf8.MaxRune)
+			if j+1 < len(bounds) {
+				hi = bounds[j+1] - 1
+			}
+			d := t.b.derive(list[i], lo)
+			if d == t.b.none {
+				continue
+			}
+			s, ok := states[d]
+			if !ok {
+				if len(list) >= maxRegexpStates {
+					return "", fmt.Errorf("regexp: %s too complex to be translated", m)
+				}
+				s = len(list)
+				states[d] = s
+				list = append(list, d)
+			}
+			k := [2]int{i, s}
+			edges[k] = append(edges[k], runeRange{lo: lo, hi: hi})
+		}
+	}
+	// generalized automaton with a start and a final state added
+	var (
+		n     = len(list)
+		start = n
+		final = n + 1
+		gnfa  = make([][]*string, n+2)
+	)
+	for i := range gnfa {
+		gnfa[i] = make([]*string, n+2)
+	}
+	// the size of the expressions is checked as soon as they are rewritten
+	// since they can grow exponentially with the number of states
+	set := func(i, j int, str string) error {
+		if gnfa[i][j] != nil {
+			str = "(?:" + *gnfa[i][j] + "|" + str + ")"
+		}
+		if len(str) > maxRegexpLength {
+			return fmt.Errorf("regexp: %s too complex to be translated", m)
+		}
+		gnfa[i][j] = &str
+		return nil
+	}
+	keys := make([][2]int, 0, len(edges))
+	for k := range edges {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	for _, k := range keys {
+		if err := set(k[0], k[1], t.class(normalizeRanges(edges[k]))); err != nil {
+			return "", err
+		}
+	}
+	gnfa[start][0] = new(string)
+	for i, x := range list {
+		if x.nullable {
+			gnfa[i][final] = new(string)
+		}
+	}
+	for k := 0; k < n; k++ {
+		var loop string
+		if r := gnfa[k][k]; r != nil && *r != "" {
+			loop = "(?:" + *r + ")*"
+		}
+		for i := range gnfa {
+			if i == k || gnfa[i][k] == nil {
+				continue
+			}
+			for j := range gnfa {
+				if j == k || gnfa[k][j] == nil {
+					continue
+				}
+				if err := set(i, j, *gnfa[i][k]+loop+*gnfa[k][j]); err != nil {
+					return "", err
+				}
+			}
+			gnfa[i][k] = nil
+		}
+		for j := range gnfa {
+			gnfa[k][j] = nil
+		}
+	}
+	r := gnfa[start][final]
+	if r == nil {
+		// nothing is matched
+		return "[^\\x00-\\x{10ffff}]", nil
+	}
+	str := *r
+	if re, err := resyntax.Parse(str, resyntax.Perl); err == nil {
+		// factor the alternatives produced by the elimination of the states
+		str = re.Simplify().String()
+	}
+	if len(str) > maxRegexpLength {
+		return "", fmt.Errorf("regexp: %s too complex to be translated", m)
+	}
+	return "(?:" + str + ")", nil
+}
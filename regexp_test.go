@@ -0,0 +1,172 @@
+package glob
+
+import (
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToRegexp(t *testing.T) {
+	data := []struct {
+		Pattern string
+		Dialect Dialect
+		Want    string
+	}{
+		{Pattern: "src/*.go", Want: `^src/[^/]*\.go$`},
+		{Pattern: "src/**/*.go", Want: `^src(?:/[^/]*)*/[^/]*\.go$`},
+		{Pattern: "**/*.go", Want: `^(?:[^/]*/)*[^/]*\.go$`},
+		{Pattern: "**", Want: `^[^/]*(?:/[^/]*)*$`},
+		{Pattern: "file?.[a-c]", Want: `^file[^/]\.[a-c]$`},
+		{Pattern: "file[!0-9]", Want: `^file[^/-9]$`},
+		{Pattern: "*.@(go|c)", Want: `^[^/]*\.(?:go|c)$`},
+		{Pattern: "a+(b|c)d", Want: `^a(?:b|c)+d$`},
+		{Pattern: "@(a/b|c)/d", Want: `^(?:a/b|c)/d$`},
+		{Pattern: "a!(b)c", Want: `^a(?:(?:)|[^/b][^/]*|b[^/][^/]*)c$`},
+		{Pattern: "stock.*.#", Dialect: AMQP, Want: `^stock\.[^.]*(?:\.[^.]*)*$`},
+		{Pattern: "*.example.com", Dialect: Hostname, Want: `(?i)^[^.][^.]*\.example\.com$`},
+	}
+	for _, d := range data {
+		got, err := ToRegexp(d.Pattern, WithDialect(d.Dialect))
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", d.Pattern, err)
+			continue
+		}
+		if got != d.Want {
+			t.Errorf("%q: want %s, got %s", d.Pattern, d.Want, got)
+		}
+	}
+}
+
+func TestToRegexpError(t *testing.T) {
+	patterns := []string{
+		"!(*a?????????)",
+		"src/[a-",
+	}
+	for _, p := range patterns {
+		if _, err := ToRegexp(p); err == nil {
+			t.Errorf("%q: expected error", p)
+		}
+	}
+}
+
+// TestToRegexpComplex checks that the translation of patterns whose
+// expressions grow exponentially stops early.
+func TestToRegexpComplex(t *testing.T) {
+	patterns := []string{
+		"*|??.",
+		"*,? {?",
+	}
+	for _, p := range patterns {
+		done := make(chan error, 1)
+		go func() {
+			_, err := ToRegexp(p, WithDialect(Zsh))
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if err == nil {
+				t.Errorf("%q: expected error", p)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%q: translation does not stop", p)
+		}
+	}
+}
+
+func TestToRegexpMatch(t *testing.T) {
+	data := []struct {
+		Pattern string
+		Dialect Dialect
+	}{
+		{Pattern: "a*/**/b?"},
+		{Pattern: "*.!(go)"},
+		{Pattern: "!(*.go)"},
+		{Pattern: "a!(b)c"},
+		{Pattern: "@(a|b/c)/**/.c"},
+		{Pattern: "*(a|bc)/?(a).b"},
+		{Pattern: "**/!(a*)/b"},
+		{Pattern: "*.c", Dialect: Bash},
+		{Pattern: "*/.a*", Dialect: Zsh},
+		{Pattern: "?(a)*", Dialect: Bash},
+		{Pattern: "{a,b*}/c", Dialect: Doublestar},
+	}
+	r := rand.New(rand.NewSource(1))
+	for _, d := range data {
+		str, err := ToRegexp(d.Pattern, WithDialect(d.Dialect))
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", d.Pattern, err)
+			continue
+		}
+		re, err := regexp.Compile(str)
+		if err != nil {
+			t.Errorf("%q: invalid expression %s: %s", d.Pattern, str, err)
+			continue
+		}
+		for i := 0; i < 2000; i++ {
+			var buf strings.Builder
+			for j, n := 0, 1+r.Intn(8); j < n; j++ {
+				buf.WriteByte("abc./"[r.Intn(5)])
+			}
+			input := strings.Trim(buf.String(), "/")
+			want := Match(input, d.Pattern, WithDialect(d.Dialect)) == nil
+			if got := re.MatchString(input); got != want {
+				t.Errorf("%q: %q should match %s: %t", d.Pattern, input, str, want)
+				break
+			}
+		}
+	}
+}
+
+func TestToRegexpWindows(t *testing.T) {
+	data := []struct {
+		Pattern string
+		Inputs  []string
+	}{
+		{
+			Pattern: `\\server\share\*`,
+			Inputs: []string{
+				`\\server\share\x`, `//server/share/x`, `\\SERVER\Share\x`, `\\\server\share\x`,
+				`\\server\share\x\y`, `\server\share\x`, `\\?\UNC\server\share\x`, `server\share\x`,
+			},
+		},
+		{
+			Pattern: `\Windows\*`,
+			Inputs:  []string{`\Windows\x`, `/windows/x`, `\Windows\x\y`, `Windows\x`, `\\Windows\x`},
+		},
+		{
+			Pattern: `C:\src\*.go`,
+			Inputs: []string{
+				`C:\src\a.go`, `c:/src/a.go`, `C:src\a.go`, `\\?\C:\src\a.go`, `D:\src\a.go`,
+				`C:\src\a\b.go`, `C:\\src\a.go`, `src\a.go`,
+			},
+		},
+		{
+			Pattern: `C:\**\*.go`,
+			Inputs:  []string{`C:\a.go`, `C:\x\y\a.go`, `c:a.go`, `C:\x\a.txt`, `\a.go`},
+		},
+		{
+			Pattern: `C:`,
+			Inputs:  []string{`C:`, `c:\`, `C:\x`},
+		},
+	}
+	for _, d := range data {
+		str, err := ToRegexp(d.Pattern, WithDialect(Windows))
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", d.Pattern, err)
+			continue
+		}
+		re, err := regexp.Compile(str)
+		if err != nil {
+			t.Errorf("%q: invalid expression %s: %s", d.Pattern, str, err)
+			continue
+		}
+		for _, input := range d.Inputs {
+			want := Match(input, d.Pattern, WithDialect(Windows)) == nil
+			if got := re.MatchString(input); got != want {
+				t.Errorf("%q: %q should match %s: %t", d.Pattern, input, str, want)
+			}
+		}
+	}
+}
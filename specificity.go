@@ -0,0 +1,205 @@
+package glob
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// Score counts the parts of a pattern that make it more or less specific.
+type Score struct {
+	Literals  int // segments without wildcards
+	Chars     int // literal characters
+	Singles   int // ? and bracket expressions
+	Stars     int // * and matchers of unknown type
+	Extglobs  int // extended patterns and groups
+	Globstars int // ** segments
+}
+
+// Compare returns a positive number when s is more specific than o, a
+// negative one when it is less specific and zero when both are equally
+// specific. The counts are compared in order: fewer **, more literal
+// segments, fewer stars, fewer extended patterns, more literal characters and
+// more single character wildcards.
+func (s Score) Compare(o Score) int {
+	switch {
+	case s.Globstars != o.Globstars:
+		return o.Globstars - s.Globstars
+	case s.Literals != o.Literals:
+		return s.Literals - o.Literals
+	case s.Stars != o.Stars:
+		return o.Stars - s.Stars
+	case s.Extglobs != o.Extglobs:
+		return o.Extglobs - s.Extglobs
+	case s.Chars != o.Chars:
+		return s.Chars - o.Chars
+	default:
+		return s.Singles - o.Singles
+	}
+}
+
+func (s *Score) add(o Score) {
+	s.Literals += o.Literals
+	s.Chars += o.Chars
+	s.Singles += o.Singles
+	s.Stars += o.Stars
+	s.Extglobs += o.Extglobs
+	s.Globstars += o.Globstars
+}
+
+// Specificity computes the score of the compiled tree of m. The alternatives
+// of a group count for their least specific one and the repeated patterns
+// only count when they have to be present.
+func Specificity(m Matcher) Score {
+	var s Score
+	for _, m := range appendChain(nil, m) {
+		switch {
+		case isGlobstar(m):
+			s.Globstars++
+		case fixedSegment(m):
+			s.Literals++
+			s.add(segmentScore(m))
+		default:
+			s.add(segmentScore(m))
+		}
+	}
+	return s
+}
+
+// SortBySpecificity sorts patterns from the most specific to the least
+// specific. The patterns that can not be compiled are put last. The order of
+// the patterns with the same score is kept.
+func SortBySpecificity(patterns []string, opts ...Option) {
+	type scored struct {
+		pattern string
+		score   Score
+		valid   bool
+	}
+	list := make([]scored, len(patterns))
+	for i, p := range patterns {
+		list[i].pattern = p
+		if m, err := Compile(p, opts...); err == nil {
+			list[i].score = Specificity(m)
+			list[i].valid = true
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].valid != list[j].valid {
+			return list[i].valid
+		}
+		return list[i].score.Compare(list[j].score) > 0
+	})
+	for i := range list {
+		patterns[i] = list[i].pattern
+	}
+}
+
+func segmentScore(m Matcher) Score {
+	var s Score
+	switch m := m.(type) {
+	case *simple:
+		s = patternScore(m.pattern)
+	case *automaton:
+		s = segmentScore(m.src)
+	case *visible:
+		s = segmentScore(m.inner)
+	case *multiple:
+		for _, m := range m.ms {
+			s.add(segmentScore(m))
+		}
+	case *group:
+		for i, m := range m.ms {
+			x := segmentScore(m)
+			if i == 0 || x.Compare(s) < 0 {
+				s = x
+			}
+		}
+		s.Extglobs++
+	case *any:
+		if m.min > 0 {
+			s = segmentScore(m.inner)
+		}
+		s.Extglobs++
+	case *not:
+		s.Extglobs++
+	case *element:
+		if m.next != nil || isGlobstar(m.head) {
+			return Specificity(m)
+		}
+		s = segmentScore(m.head)
+	default:
+		s.Stars++
+	}
+	return s
+}
+
+func patternScore(pat string) Score {
+	var s Score
+	for i := 0; i < len(pat); {
+		k, n := utf8.DecodeRuneInString(pat[i:])
+		switch {
+		case k == star:
+			s.Stars++
+		case k == mark:
+			s.Singles++
+		case k == lsquare && classSize(pat[i:]) > 0:
+			n = classSize(pat[i:])
+			s.Singles++
+		case k == backslash && i+n < len(pat):
+			_, z := utf8.DecodeRuneInString(pat[i+n:])
+			n += z
+			s.Chars++
+		default:
+			s.Chars++
+		}
+		i += n
+	}
+	return s
+}
+
+// fixedSegment reports whether m only matches fixed strings: its patterns
+// and the ones of its alternatives have no wildcard nor bracket expression.
+func fixedSegment(m Matcher) bool {
+	switch m := m.(type) {
+	case *simple:
+		return !isGlobstar(m) && fixedPattern(m.pattern)
+	case *automaton:
+		return fixedSegment(m.src)
+	case *visible:
+		return fixedSegment(m.inner)
+	case *multiple:
+		for _, m := range m.ms {
+			if !fixedSegment(m) {
+				return false
+			}
+		}
+		return true
+	case *group:
+		for _, m := range m.ms {
+			if !fixedSegment(m) {
+				return false
+			}
+		}
+		return len(m.ms) > 0
+	case *element:
+		return m.next == nil && fixedSegment(m.head)
+	default:
+		return false
+	}
+}
+
+func fixedPattern(pat string) bool {
+	for i := 0; i < len(pat); {
+		k, n := utf8.DecodeRuneInString(pat[i:])
+		switch {
+		case k == star || k == mark:
+			return false
+		case k == lsquare && classSize(pat[i:]) > 0:
+			return false
+		case k == backslash && i+n < len(pat):
+			_, z := utf8.DecodeRuneInString(pat[i+n:])
+			n += z
+		}
+		i += n
+	}
+	return true
+}
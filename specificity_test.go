@@ -0,0 +1,63 @@
+package glob
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSpecificity(t *testing.T) {
+	data := []struct {
+		Pattern string
+		Score   Score
+	}{
+		{Pattern: "a/b/c", Score: Score{Literals: 3, Chars: 3}},
+		{Pattern: "a/*/c", Score: Score{Literals: 2, Chars: 2, Stars: 1}},
+		{Pattern: "a/?*/c", Score: Score{Literals: 2, Chars: 2, Stars: 1, Singles: 1}},
+		{Pattern: "src/**/*.go", Score: Score{Literals: 1, Chars: 6, Stars: 1, Globstars: 1}},
+		{Pattern: "*.@(go|c)", Score: Score{Chars: 2, Stars: 1, Extglobs: 1}},
+		{Pattern: "a+(bc)d", Score: Score{Chars: 4, Extglobs: 1}},
+		{Pattern: "a*(bc)d", Score: Score{Chars: 2, Extglobs: 1}},
+		{Pattern: "!(*.go)", Score: Score{Extglobs: 1}},
+		{Pattern: "file[0-9].txt", Score: Score{Chars: 8, Singles: 1}},
+		{Pattern: "@(a/b|c)/d", Score: Score{Literals: 1, Chars: 2, Extglobs: 1}},
+	}
+	for _, d := range data {
+		m, err := Compile(d.Pattern)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", d.Pattern, err)
+			continue
+		}
+		if got := Specificity(m); got != d.Score {
+			t.Errorf("%q: score mismatched: want %+v, got %+v", d.Pattern, d.Score, got)
+		}
+	}
+}
+
+func TestSortBySpecificity(t *testing.T) {
+	patterns := []string{
+		"**",
+		"[a-",
+		"a/*/c",
+		"a/**/c",
+		"*.@(go|c)",
+		"a/b/c",
+		"a/?*/c",
+		"*.go",
+		"a/?/c",
+	}
+	want := []string{
+		"a/b/c",
+		"a/?/c",
+		"a/?*/c",
+		"a/*/c",
+		"*.go",
+		"*.@(go|c)",
+		"a/**/c",
+		"**",
+		"[a-",
+	}
+	SortBySpecificity(patterns)
+	if !reflect.DeepEqual(patterns, want) {
+		t.Errorf("patterns not sorted: want %q, got %q", want, patterns)
+	}
+}
@@ -0,0 +1,389 @@
+package glob
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	likeEscape = backslash
+	likeAny    = '%'
+	likeOne    = '_'
+)
+
+// SQLFilter is a predicate of a SQL query selecting the paths matched by a
+// pattern. The paths are expected to be stored without leading or trailing
+// separators.
+type SQLFilter struct {
+	// Where is the condition with ? for the parameters given by Args.
+	Where string
+	Args  []interface{}
+	// Residual matches exactly the paths selected by Where that are matched
+	// by the pattern. It is nil when a case sensitive LIKE selects only
+	// these paths.
+	Residual *Pattern
+}
+
+// ToLike translates pattern to a LIKE condition on column selecting at least
+// all the paths matched by pattern. The wildcards become % and _, the parts
+// of the pattern that LIKE can not express are replaced by % and a residual
+// Pattern is returned to filter the selected rows. Column is written as
+// given in the condition.
+//
+// The condition is exact, without Residual, only for a case sensitive LIKE
+// as in PostgreSQL. LIKE is case insensitive by default in SQLite where the
+// case_sensitive_like pragma has then to be set. Otherwise the selected rows
+// have to be filtered by Residual when it is set or by a Pattern compiled
+// from pattern when it is nil.
+func ToLike(pattern, column string, opts ...Option) (*SQLFilter, error) {
+	p, err := sqlPattern(pattern, opts)
+	if err != nil {
+		return nil, err
+	}
+	t := likeTranslator{
+		sep:   string(p.sep),
+		exact: true,
+	}
+	var (
+		buf   strings.Builder
+		ms    = appendChain(nil, optimize(unautomate(p.Matcher)))
+		after bool // a separator is needed before the next segment
+	)
+	for _, m := range ms {
+		if isGlobstar(m) {
+			// ** also matches no segment: it absorbs its separators
+			buf.WriteRune(likeAny)
+			t.exact, after = false, false
+			continue
+		}
+		if after {
+			buf.WriteString(t.quote(t.sep))
+		}
+		buf.WriteString(t.head(m))
+		after = true
+	}
+	f := SQLFilter{
+		Where: fmt.Sprintf("%s LIKE ? ESCAPE '%c'", column, likeEscape),
+		Args:  []interface{}{collapseAny(buf.String())},
+	}
+	if !t.exact {
+		f.Residual = p
+	}
+	return &f, nil
+}
+
+// ToGlob translates pattern to a SQLite GLOB condition on column. An error
+// is returned when GLOB can not select exactly the paths matched by pattern,
+// which is the case for * and ** since GLOB wildcards also match separators.
+func ToGlob(pattern, column string, opts ...Option) (*SQLFilter, error) {
+	p, err := sqlPattern(pattern, opts)
+	if err != nil {
+		return nil, err
+	}
+	t := globTranslator{
+		seps: []runeRange{{lo: rune(p.sep), hi: rune(p.sep)}},
+	}
+	var list []string
+	for _, m := range appendChain(nil, optimize(unautomate(p.Matcher))) {
+		str, err := t.segment(m, true)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, str)
+	}
+	f := SQLFilter{
+		Where: fmt.Sprintf("%s GLOB ?", column),
+		Args:  []interface{}{strings.Join(list, string(p.sep))},
+	}
+	return &f, nil
+}
+
+// sqlPattern compiles pattern for the dialects whose paths can be compared
+// without normalization.
+func sqlPattern(pattern string, opts []Option) (*Pattern, error) {
+	p, err := CompilePattern(pattern, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if p.sep == 0 {
+		return nil, fmt.Errorf("sql: %s paths can not be compared as stored", p.cfg.dialect)
+	}
+	return p, nil
+}
+
+// unautomate replaces the automata of m by their source matcher.
+func unautomate(m Matcher) Matcher {
+	switch x := m.(type) {
+	case *automaton:
+		return x.src
+	case *element:
+		ms := appendChain(nil, x)
+		for i := range ms {
+			ms[i] = unautomate(ms[i])
+		}
+		return linkMatchers(ms)
+	case *group:
+		ms := make([]Matcher, len(x.ms))
+		for i := range x.ms {
+			ms[i] = unautomate(x.ms[i])
+		}
+		return &group{ms: ms}
+	default:
+		return m
+	}
+}
+
+type likeTranslator struct {
+	sep   string
+	exact bool
+}
+
+func (t *likeTranslator) head(m Matcher) string {
+	switch m := m.(type) {
+	case *simple:
+		return t.pattern(m.pattern)
+	case *multiple:
+		var buf strings.Builder
+		for _, m := range m.ms {
+			buf.WriteString(t.head(m))
+		}
+		return buf.String()
+	case *visible:
+		return t.head(m.inner)
+	case *element:
+		if m.next == nil && !isGlobstar(m.head) {
+			return t.head(m.head)
+		}
+	case *group:
+		if spanSegments(m) {
+			t.exact = false
+			return string(likeAny)
+		}
+	}
+	// the required literals are kept: only the prefix since it can overlap
+	// the suffix in short strings
+	l := literalsOf(m)
+	if l.exact {
+		return t.quote(l.prefix)
+	}
+	t.exact = false
+	if l.prefix == "" && l.suffix != "" {
+		return string(likeAny) + t.quote(l.suffix)
+	}
+	return t.quote(l.prefix) + string(likeAny)
+}
+
+func (t *likeTranslator) pattern(pat string) string {
+	var buf strings.Builder
+	for i := 0; i < len(pat); {
+		k, n := utf8.DecodeRuneInString(pat[i:])
+		switch {
+		case k == star:
+			buf.WriteRune(likeAny)
+			t.exact = false
+		case k == mark:
+			buf.WriteRune(likeOne)
+			t.exact = false
+		case k == lsquare && classSize(pat[i:]) > 0:
+			n = classSize(pat[i:])
+			buf.WriteRune(likeOne)
+			t.exact = false
+		case k == backslash && i+n < len(pat):
+			k, z := utf8.DecodeRuneInString(pat[i+n:])
+			n += z
+			buf.WriteString(t.quote(string(k)))
+		default:
+			buf.WriteString(t.quote(string(k)))
+		}
+		i += n
+	}
+	return buf.String()
+}
+
+func (t *likeTranslator) quote(str string) string {
+	if !strings.ContainsAny(str, "%_\\") {
+		return str
+	}
+	var buf strings.Builder
+	for _, k := range str {
+		if k == likeAny || k == likeOne || k == likeEscape {
+			buf.WriteRune(likeEscape)
+		}
+		buf.WriteRune(k)
+	}
+	return buf.String()
+}
+
+// collapseAny removes the runs of % left by consecutive wildcards.
+func collapseAny(str string) string {
+	var (
+		buf     strings.Builder
+		escaped bool
+		last    bool // last rune is an unescaped %
+	)
+	for _, k := range str {
+		wild := k == likeAny && !escaped
+		if wild && last {
+			continue
+		}
+		last = wild
+		escaped = !escaped && k == likeEscape
+		buf.WriteRune(k)
+	}
+	return buf.String()
+}
+
+type globTranslator struct {
+	seps []runeRange
+}
+
+// segment translates m. Leading tells if m starts the segment.
+func (t *globTranslator) segment(m Matcher, leading bool) (string, error) {
+	switch m := m.(type) {
+	case *simple:
+		if !isGlobstar(m) {
+			return t.pattern(m.pattern, leading, false)
+		}
+	case *visible:
+		if s, ok := m.inner.(*simple); ok {
+			return t.pattern(s.pattern, leading, true)
+		}
+	case *multiple:
+		var buf strings.Builder
+		for i, m := range m.ms {
+			str, err := t.segment(m, leading && i == 0)
+			if err != nil {
+				return "", err
+			}
+			buf.WriteString(str)
+		}
+		return buf.String(), nil
+	case *element:
+		if m.next == nil {
+			return t.segment(m.head, leading)
+		}
+	}
+	return "", fmt.Errorf("sql: %s can not be expressed exactly with GLOB", m)
+}
+
+// pattern translates the pattern of a simple matcher. The first character
+// does not match a dot when hidden is set.
+func (t *globTranslator) pattern(pat string, leading, hidden bool) (string, error) {
+	var (
+		buf  strings.Builder
+		seps = t.seps
+	)
+	if leading && hidden {
+		seps = normalizeRanges(append([]runeRange{{lo: '.', hi: '.'}}, seps...))
+	}
+	for i := 0; i < len(pat); {
+		k, n := utf8.DecodeRuneInString(pat[i:])
+		switch {
+		case k == star:
+			return "", fmt.Errorf("sql: %s can not be expressed exactly with GLOB", toExtended(pat))
+		case k == mark:
+			buf.WriteString(globClass(negateRanges(seps)))
+		case k == lsquare && classSize(pat[i:]) > 0:
+			n = classSize(pat[i:])
+			rs := classRanges(pat[i+1 : i+n-1])
+			buf.WriteString(globClass(intersectRanges(rs, negateRanges(seps))))
+		case k == backslash && i+n < len(pat):
+			k, z := utf8.DecodeRuneInString(pat[i+n:])
+			n += z
+			buf.WriteString(globQuote(k))
+		default:
+			buf.WriteString(globQuote(k))
+		}
+		seps = t.seps
+		i += n
+	}
+	return buf.String(), nil
+}
+
+func globQuote(k rune) string {
+	switch k {
+	case star, mark, lsquare:
+		return string([]rune{lsquare, k, rsquare})
+	default:
+		return string(k)
+	}
+}
+
+// globClass writes a bracket expression of GLOB. The characters are written
+// as is: ] is a literal at the beginning, - at the end and ^ after the first
+// character.
+func globClass(rs []runeRange) string {
+	if len(rs) == 0 {
+		return "[^\x00-\U0010FFFF]"
+	}
+	if len(rs) == 1 && rs[0].lo == rs[0].hi {
+		return globQuote(rs[0].lo)
+	}
+	var buf strings.Builder
+	buf.WriteRune(lsquare)
+	if neg := negateRanges(rs); len(neg) > 0 && len(neg) < len(rs) {
+		buf.WriteRune(caret)
+		rs = neg
+	}
+	has := func(k rune) bool {
+		for _, r := range rs {
+			if r.lo <= k && k <= r.hi {
+				return true
+			}
+		}
+		return false
+	}
+	var (
+		bracket = has(rsquare)
+		minus   = has(dash)
+		hat     = has(caret)
+		list    = splitRanges(rs, rsquare, dash, caret)
+	)
+	if bracket {
+		buf.WriteRune(rsquare)
+	}
+	if hat && !bracket && len(list) == 0 {
+		// nothing else to write before ^: - has to come first
+		buf.WriteRune(dash)
+		minus = false
+	}
+	for _, r := range list {
+		buf.WriteRune(r.lo)
+		if r.hi > r.lo {
+			if r.hi > r.lo+1 {
+				buf.WriteRune(dash)
+			}
+			buf.WriteRune(r.hi)
+		}
+	}
+	if hat {
+		buf.WriteRune(caret)
+	}
+	if minus {
+		buf.WriteRune(dash)
+	}
+	buf.WriteRune(rsquare)
+	return buf.String()
+}
+
+// splitRanges removes the characters ks from rs.
+func splitRanges(rs []runeRange, ks ...rune) []runeRange {
+	for _, k := range ks {
+		var list []runeRange
+		for _, r := range rs {
+			if k < r.lo || k > r.hi {
+				list = append(list, r)
+				continue
+			}
+			if r.lo < k {
+				list = append(list, runeRange{lo: r.lo, hi: k - 1})
+			}
+			if k < r.hi {
+				list = append(list, runeRange{lo: k + 1, hi: r.hi})
+			}
+		}
+		rs = list
+	}
+	return rs
+}
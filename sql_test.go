@@ -0,0 +1,206 @@
+package glob
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestToLike(t *testing.T) {
+	data := []struct {
+		Pattern string
+		Like    string
+		Exact   bool
+		Opts    []Option
+	}{
+		{Pattern: "src/main.go", Like: "src/main.go", Exact: true},
+		{Pattern: "src/*.go", Like: "src/%.go"},
+		{Pattern: "src/**/*_test.go", Like: `src%\_test.go`},
+		{Pattern: "**/*.go", Like: "%.go"},
+		{Pattern: "docs/**", Like: "docs%"},
+		{Pattern: "a/**/b/**/c", Like: "a%b%c"},
+		{Pattern: "file?.[ch]", Like: "file_._"},
+		{Pattern: "100%/x_y", Like: `100\%/x\_y`, Exact: true},
+		{Pattern: "100%*", Like: `100\%%`},
+		{Pattern: `a\\b`, Like: `a\\b`, Exact: true},
+		{Pattern: "@(dev|prod)/app.conf", Like: "%/app.conf"},
+		{Pattern: "config.@(yaml|json)", Like: "config.%"},
+		{Pattern: "*.!(txt)", Like: "%.%"},
+		{Pattern: "@(a/b|c)/d", Like: "%/d"},
+		{Pattern: "sensors/+/temp", Like: "sensors/%/temp", Opts: []Option{WithDialect(MQTT)}},
+		{Pattern: "logs.#", Like: "logs%", Opts: []Option{WithDialect(AMQP)}},
+	}
+	for _, d := range data {
+		f, err := ToLike(d.Pattern, "path", d.Opts...)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", d.Pattern, err)
+			continue
+		}
+		if f.Where != `path LIKE ? ESCAPE '\'` {
+			t.Errorf("%q: unexpected condition %s", d.Pattern, f.Where)
+		}
+		if len(f.Args) != 1 || f.Args[0] != d.Like {
+			t.Errorf("%q: like mismatched! want %q, got %q", d.Pattern, d.Like, f.Args)
+		}
+		if exact := f.Residual == nil; exact != d.Exact {
+			t.Errorf("%q: exact mismatched! want %t, got %t", d.Pattern, d.Exact, exact)
+		}
+	}
+}
+
+func TestToGlob(t *testing.T) {
+	data := []struct {
+		Pattern string
+		Glob    string
+		Opts    []Option
+	}{
+		{Pattern: "src/main.go", Glob: "src/main.go"},
+		{Pattern: "file?.[ch]", Glob: "file[^/].[ch]"},
+		{Pattern: "[!a-c]x", Glob: "[^/a-c]x"},
+		{Pattern: `[\]-]`, Glob: "[]-]"},
+		{Pattern: `[\^a]`, Glob: "[a^]"},
+		{Pattern: `[\^\-]`, Glob: "[-^]"},
+		{Pattern: `a\*b\?[[]`, Glob: "a[*]b[?][[]"},
+		{Pattern: "?.txt", Glob: "[^./].txt", Opts: []Option{WithDialect(Zsh)}},
+		{Pattern: "sensors/dev?/temp", Glob: "sensors/dev[?]/temp", Opts: []Option{WithDialect(MQTT)}},
+	}
+	for _, d := range data {
+		f, err := ToGlob(d.Pattern, "path", d.Opts...)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", d.Pattern, err)
+			continue
+		}
+		if f.Where != "path GLOB ?" {
+			t.Errorf("%q: unexpected condition %s", d.Pattern, f.Where)
+		}
+		if len(f.Args) != 1 || f.Args[0] != d.Glob {
+			t.Errorf("%q: glob mismatched! want %q, got %q", d.Pattern, d.Glob, f.Args)
+		}
+	}
+}
+
+func TestToGlobError(t *testing.T) {
+	data := []struct {
+		Pattern string
+		Opts    []Option
+	}{
+		{Pattern: "*.go"},
+		{Pattern: "src/**"},
+		{Pattern: "@(a|b)"},
+		{Pattern: "!(a)"},
+		{Pattern: "sensors/+/temp", Opts: []Option{WithDialect(MQTT)}},
+		{Pattern: "C:\\Temp", Opts: []Option{WithDialect(Windows)}},
+		{Pattern: "www.example.com", Opts: []Option{WithDialect(Hostname)}},
+	}
+	for _, d := range data {
+		if f, err := ToGlob(d.Pattern, "path", d.Opts...); err == nil {
+			t.Errorf("%q: expected error, got %q", d.Pattern, f.Args)
+		}
+	}
+}
+
+// TestToSQLMatch checks that LIKE selects at least the paths matched by the
+// patterns and that GLOB selects exactly them.
+func TestToSQLMatch(t *testing.T) {
+	patterns := []string{
+		"src/main.go",
+		"src/*.go",
+		"src/**/*_test.go",
+		"**/*.go",
+		"docs/**",
+		"a/**/b",
+		"file?.[ch]",
+		"[!a-c]?",
+		"@(dev|prod)/config.@(yaml|json)",
+		"*.!(txt)",
+		"+(ab)",
+		"@(a/b|c)/d",
+	}
+	inputs := []string{
+		"src/main.go", "src/glob.go", "src/a/b/c_test.go", "src/c_test.go",
+		"main.go", "docs", "docs/a/b.md", "docs.md", "a/b", "a/x/y/b", "ab",
+		"file1.c", "file/.c", "file.h", "dx", "ax", "d/", "dev/config.yaml",
+		"prod/config.json", "qa/config.yaml", "x.txt", "x.md", "x", "ab",
+		"abab", "aba", "a/b/d", "c/d", "e/d",
+	}
+	for _, p := range patterns {
+		m, err := CompilePattern(p)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", p, err)
+		}
+		like, err := ToLike(p, "path")
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", p, err)
+			continue
+		}
+		glob, _ := ToGlob(p, "path")
+		for _, str := range inputs {
+			want := m.MatchString(str)
+			if want && !likeMatch(like.Args[0].(string), str) {
+				t.Errorf("%q: %q not selected by LIKE %q", p, str, like.Args[0])
+			}
+			if want && like.Residual != nil && !like.Residual.MatchString(str) {
+				t.Errorf("%q: %q not matched by residual", p, str)
+			}
+			if like.Residual == nil && want != likeMatch(like.Args[0].(string), str) {
+				t.Errorf("%q: %q: exact LIKE %q mismatched", p, str, like.Args[0])
+			}
+			if glob != nil && want != globMatch(glob.Args[0].(string), str) {
+				t.Errorf("%q: %q: GLOB %q mismatched! want %t", p, str, glob.Args[0], want)
+			}
+		}
+	}
+}
+
+// likeMatch evaluates a case sensitive LIKE with \ as escape character.
+func likeMatch(like, str string) bool {
+	var buf strings.Builder
+	buf.WriteString("^")
+	for i := 0; i < len(like); i++ {
+		switch k := like[i]; k {
+		case '%':
+			buf.WriteString("(?s:.*)")
+		case '_':
+			buf.WriteString("(?s:.)")
+		case '\\':
+			i++
+			buf.WriteString(regexp.QuoteMeta(like[i : i+1]))
+		default:
+			buf.WriteString(regexp.QuoteMeta(like[i : i+1]))
+		}
+	}
+	buf.WriteString("$")
+	return regexp.MustCompile(buf.String()).MatchString(str)
+}
+
+// globMatch evaluates a SQLite GLOB.
+func globMatch(glob, str string) bool {
+	var buf strings.Builder
+	buf.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch k := glob[i]; k {
+		case '*':
+			buf.WriteString("(?s:.*)")
+		case '?':
+			buf.WriteString("(?s:.)")
+		case '[':
+			j := i + 1
+			if j < len(glob) && glob[j] == '^' {
+				j++
+			}
+			if j < len(glob) && glob[j] == ']' {
+				j++
+			}
+			j += strings.IndexByte(glob[j:], ']')
+			class := strings.ReplaceAll(glob[i+1:j], `\`, `\\`)
+			class = strings.Replace(class, "]", `\]`, 1)
+			class = strings.ReplaceAll(class, "[", `\[`)
+			buf.WriteString("[" + class + "]")
+			i = j
+		default:
+			buf.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	buf.WriteString("$")
+	return regexp.MustCompile(buf.String()).MatchString(str)
+}
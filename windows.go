@@ -0,0 +1,110 @@
+package glob
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/midbel/glob/ast"
+)
+
+const (
+	winSeparators = `\/`
+
+	uncPrefix  = `\\`
+	rootPrefix = `\`
+)
+
+// compileWindows compiles a pattern written for Windows paths:
+//
+//   - segments are separated by backslashes or slashes
+//   - special characters are escaped with a backtick
+//   - a drive letter (C:) forms the first segment of the path
+//   - a path starting with two separators is an UNC path. The \\?\ and
+//     \\?\UNC\ prefixes of long paths are ignored
+//   - paths are compared without regard to case
+//
+// The rest of the syntax is the same as the Extended dialect.
+func compileWindows(pattern string, cfg *config) (Matcher, error) {
+	var (
+		syn      = cfg.syntax(windowsSyntax)
+		from, to = ast.TrimSpace(pattern, syn.Escape)
+	)
+	prefix, n := windowsPrefix(pattern[from:to])
+	if prefix != "" && from+n >= to {
+		return &simple{pattern: quote(lowerCase(prefix))}, nil
+	}
+	m, err := parse(lowerCase(pattern), from+n, to, syn)
+	if e, ok := err.(*PatternError); ok {
+		e.Pattern = pattern
+	}
+	if err != nil || prefix == "" {
+		return m, err
+	}
+	return &element{
+		head: &simple{pattern: quote(lowerCase(prefix))},
+		next: m,
+	}, nil
+}
+
+func splitWindows(str string) []string {
+	str = lowerCase(str)
+	prefix, n := windowsPrefix(str)
+	parts := strings.FieldsFunc(str[n:], func(k rune) bool {
+		return strings.ContainsRune(winSeparators, k)
+	})
+	if prefix != "" {
+		parts = append([]string{prefix}, parts...)
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "")
+	}
+	return parts
+}
+
+// windowsPrefix returns the segment identifying the root of str and the
+// number of bytes it spans. The root is \\ for UNC paths, \ for paths relative
+// to the root of the current drive, the drive letter followed by a colon for
+// paths on a given drive and empty otherwise.
+func windowsPrefix(str string) (string, int) {
+	isSep := func(b byte) bool {
+		return b == backslash || b == slash
+	}
+	var n int
+	if len(str) >= 4 && isSep(str[0]) && isSep(str[1]) && (str[2] == mark || str[2] == '.') && isSep(str[3]) {
+		n = 4
+		if len(str) >= n+4 && strings.EqualFold(str[n:n+3], "unc") && isSep(str[n+3]) {
+			return uncPrefix, n + 4
+		}
+	}
+	str = str[n:]
+	switch {
+	case len(str) >= 2 && isSep(str[0]) && isSep(str[1]):
+		return uncPrefix, n + 2
+	case len(str) >= 1 && isSep(str[0]):
+		return rootPrefix, n + 1
+	case len(str) >= 2 && isDrive(str[0]) && str[1] == ':':
+		n += 2
+		if len(str) > 2 && isSep(str[2]) {
+			n++
+		}
+		return str[:2], n
+	default:
+		return "", n
+	}
+}
+
+// lowerCase converts str to lower case without changing its length so that
+// offsets in str remain valid.
+func lowerCase(str string) string {
+	return strings.Map(func(k rune) rune {
+		if z := unicode.ToLower(k); utf8.RuneLen(z) == utf8.RuneLen(k) {
+			return z
+		}
+		return k
+	}, str)
+}
+
+func isDrive(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}